@@ -7,7 +7,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/m-mizutani/clog"
 	"github.com/m-mizutani/ctxlog"
@@ -15,10 +19,21 @@ import (
 	"github.com/m-mizutani/zenv/v2/pkg/executor"
 	"github.com/m-mizutani/zenv/v2/pkg/loader"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+	"github.com/m-mizutani/zenv/v2/pkg/trust"
 	"github.com/m-mizutani/zenv/v2/pkg/usecase"
 	"golang.org/x/term"
 )
 
+const usage = `Usage: zenv [options] <command> [args...]
+       zenv [options] run <task> [args...]
+       zenv [options] tasks
+       zenv [options] start [Procfile]
+       zenv [options] shell
+       zenv [options] hook bash|zsh|fish
+       zenv [options] allow|deny [FILE...]
+`
+
 // newConfigLoader picks the appropriate loader based on the file extension.
 // Files ending in .hcl use the HCL loader; everything else falls back to YAML.
 func newConfigLoader(path, profile string, existingVars []*model.EnvVar) loader.LoadFunc {
@@ -28,6 +43,9 @@ func newConfigLoader(path, profile string, existingVars []*model.EnvVar) loader.
 	return loader.NewYAMLLoaderWithProfile(path, profile, existingVars)
 }
 
+// defaultExecMode lets the command replace zenv when no redaction is needed
+var defaultExecMode = executor.ExecAuto
+
 // Format represents the log output format
 type Format int
 
@@ -40,12 +58,13 @@ const (
 // NewLogger creates a new slog.Logger with automatic format detection
 // If output is a terminal, use clog for colored console output
 // Otherwise, use JSON format for structured logging
-func NewLogger(level slog.Level, w io.Writer) *slog.Logger {
-	return NewLoggerWithFormat(level, w, FormatAuto)
+// Values registered in secrets are masked in log output; secrets may be nil.
+func NewLogger(level slog.Level, w io.Writer, secrets *redact.Secrets) *slog.Logger {
+	return NewLoggerWithFormat(level, w, FormatAuto, secrets)
 }
 
 // NewLoggerWithFormat creates a new slog.Logger with specified format
-func NewLoggerWithFormat(level slog.Level, w io.Writer, format Format) *slog.Logger {
+func NewLoggerWithFormat(level slog.Level, w io.Writer, format Format, secrets *redact.Secrets) *slog.Logger {
 	if w == nil {
 		w = os.Stdout
 	}
@@ -75,6 +94,10 @@ func NewLoggerWithFormat(level slog.Level, w io.Writer, format Format) *slog.Log
 		})
 	}
 
+	if secrets != nil {
+		handler = redact.NewHandler(handler, secrets)
+	}
+
 	return slog.New(handler)
 }
 
@@ -94,7 +117,14 @@ func ParseLogLevel(level string) slog.Level {
 	}
 }
 
+// Run executes zenv with the given command line arguments. Secret values
+// resolved during the run are masked in the returned error message.
 func Run(ctx context.Context, args []string) error {
+	secrets := redact.NewSecrets()
+	return secrets.ScrubError(run(ctx, args, secrets))
+}
+
+func run(ctx context.Context, args []string, secrets *redact.Secrets) error {
 	// Create parser with options
 	parser, err := NewParser([]Option{
 		{
@@ -118,7 +148,17 @@ func Run(ctx context.Context, args []string) error {
 		{
 			Name:    "profile",
 			Aliases: []string{"p"},
-			Usage:   "Select profile from YAML configuration",
+			Usage:   "Select profile from YAML configuration; a comma-separated list runs the command once for each profile",
+		},
+		{
+			Name:      "each-profile",
+			Usage:     "Run the command once for each profile defined in the configuration",
+			IsBoolean: true,
+		},
+		{
+			Name:      "parallel",
+			Usage:     "Run the command for all profiles at the same time",
+			IsBoolean: true,
 		},
 		{
 			Name:         "log-level",
@@ -132,6 +172,160 @@ func Run(ctx context.Context, args []string) error {
 			Usage:     "Enable template expansion for command arguments using text/template syntax",
 			IsBoolean: true,
 		},
+		{
+			Name:    "chdir",
+			Aliases: []string{"C"},
+			Usage:   "Run the command in DIR (files given with -e/-c are still relative to the current directory)",
+		},
+		{
+			Name:      "discover-from-chdir",
+			Usage:     "Discover default .env and config files from the -C directory instead of the current directory",
+			IsBoolean: true,
+		},
+		{
+			Name:      "ignore-environment",
+			Aliases:   []string{"i"},
+			Usage:     "Start with an empty environment instead of the system environment",
+			IsBoolean: true,
+		},
+		{
+			Name:    "unset",
+			Aliases: []string{"u"},
+			Usage:   "Remove a variable from the inherited system environment",
+			IsSlice: true,
+		},
+		{
+			Name:      "allow-secret-args",
+			Usage:     "Allow template expansion to put secret values into command arguments",
+			IsBoolean: true,
+		},
+		{
+			Name:      "trust",
+			Usage:     "Run commands from configuration files that were not allowed, e.g. in CI",
+			IsBoolean: true,
+		},
+		{
+			Name:    "secret",
+			Aliases: []string{"s"},
+			Usage:   "Set a secret environment variable (KEY=value), masked in listing and output",
+			IsSlice: true,
+		},
+		{
+			Name:    "secret-pattern",
+			Usage:   "Additional variable name glob to treat as secret (e.g. '*_DSN')",
+			IsSlice: true,
+		},
+		{
+			Name:         "secret-entropy",
+			Usage:        "Entropy threshold (bits/char) for treating token-like values as secret (0 to disable)",
+			DefaultValue: strconv.FormatFloat(usecase.DefaultSecretEntropy, 'f', -1, 64),
+		},
+		{
+			Name:      "no-auto-secret",
+			Usage:     "Disable automatic secret classification by variable name and value entropy",
+			IsBoolean: true,
+		},
+		{
+			Name:  "mask",
+			Usage: "Masking policy for secret values: fixed, name, partial[:N] or hash (default: fixed, or mask in config)",
+		},
+		{
+			Name:      "exec",
+			Usage:     "Replace zenv with the command (exec) and warn when redaction requires a child process (default: exec when possible)",
+			IsBoolean: true,
+		},
+		{
+			Name:      "no-exec",
+			Usage:     "Always run the command as a child process of zenv",
+			IsBoolean: true,
+		},
+		{
+			Name:      "pty",
+			Usage:     "Run the command on a pseudo-terminal when its output is redacted, keeping colours and line editing (Linux only)",
+			IsBoolean: true,
+		},
+		{
+			Name:         "kill-grace",
+			Usage:        "Time the command may take to exit after a terminating signal before it is killed (0 never kills)",
+			DefaultValue: executor.DefaultKillGrace.String(),
+		},
+		{
+			Name:  "timeout",
+			Usage: "Stop the command if it runs longer than DURATION and exit with code 124",
+		},
+		{
+			Name:         "retry",
+			Usage:        "Run a failed command again up to N times",
+			DefaultValue: "0",
+		},
+		{
+			Name:         "retry-delay",
+			Usage:        "Time to wait before the first retry",
+			DefaultValue: "1s",
+		},
+		{
+			Name:         "retry-backoff",
+			Usage:        "Factor the retry delay is multiplied by after each retry",
+			DefaultValue: "2",
+		},
+		{
+			Name:    "retry-on-exit-codes",
+			Usage:   "Comma-separated exit codes that are retried (default: any failure)",
+			IsSlice: true,
+		},
+		{
+			Name:         "on-exit",
+			Usage:        "What zenv start does when a process exits: stop the others, stop them only on failure, or continue",
+			DefaultValue: string(usecase.OnExitStop),
+		},
+		{
+			Name:      "watch",
+			Usage:     "Restart the command when a file that contributed to the environment changes",
+			IsBoolean: true,
+		},
+		{
+			Name:         "watch-debounce",
+			Usage:        "Time watched files must stay unchanged before the command is restarted",
+			DefaultValue: usecase.DefaultWatchDebounce.String(),
+		},
+		{
+			Name:  "output-log",
+			Usage: "Also write the command's redacted stdout and stderr to FILE",
+		},
+		{
+			Name:         "output-log-format",
+			Usage:        "Format of the output log: text or jsonl",
+			DefaultValue: "text",
+		},
+		{
+			Name:      "output-log-timestamps",
+			Usage:     "Prefix each line of a text output log with its time",
+			IsBoolean: true,
+		},
+		{
+			Name:      "output-log-tags",
+			Usage:     "Prefix each line of a text output log with [out] or [err]",
+			IsBoolean: true,
+		},
+		{
+			Name:  "report",
+			Usage: "Write a JSON report of the loaded files, variables, command and result to FILE",
+		},
+		{
+			Name:      "report-values",
+			Usage:     "Include the values of non-secret variables in the report",
+			IsBoolean: true,
+		},
+		{
+			Name:         "redact-flush-delay",
+			Usage:        "Maximum time redacted output that cannot be a secret is held back (0 to wait for newline or exit)",
+			DefaultValue: executor.DefaultFlushDelay.String(),
+		},
+		{
+			Name:    "redact-encoding",
+			Usage:   "Encoded forms of secrets to redact in output: base64, url, json, hex or none (default: all)",
+			IsSlice: true,
+		},
 	})
 	if err != nil {
 		return goerr.Wrap(err, "failed to create parser")
@@ -142,13 +336,13 @@ func Run(ctx context.Context, args []string) error {
 	if err != nil {
 		// Check if help was requested
 		if errors.Is(err, ErrHelpRequested) {
-			_, _ = os.Stdout.WriteString("Usage: zenv [options] <command> [args...]\n\n")
+			_, _ = os.Stdout.WriteString(usage + "\n")
 			_, _ = os.Stdout.WriteString("Options:\n")
 			_, _ = os.Stdout.WriteString(parser.Help() + "\n")
 			return nil
 		}
 		// Show help message with error
-		_, _ = os.Stderr.WriteString("\nUsage: zenv [options] <command> [args...]\n\n")
+		_, _ = os.Stderr.WriteString("\n" + usage + "\n")
 		_, _ = os.Stderr.WriteString("Options:\n")
 		_, _ = os.Stderr.WriteString(parser.Help() + "\n")
 		return err
@@ -162,84 +356,535 @@ func Run(ctx context.Context, args []string) error {
 	enableTemplate := result.Options["template"].IsSet()
 	commandArgs := result.Args
 
+	// Subcommands are recognized unless the command follows "--"
+	var subcommand string
+	if len(result.Args) > 0 && !result.Terminated {
+		switch result.Args[0] {
+		case "run", "tasks", "start", "shell", "hook", "export", "allow", "deny":
+			subcommand = result.Args[0]
+		}
+	}
+
+	// The hook calls zenv export with the options given before "hook"
+	if subcommand == "hook" {
+		if len(result.Args) != 2 {
+			return goerr.New("shell is required: zenv hook bash|zsh|fish")
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			exe = "zenv"
+		}
+		command := append([]string{exe}, args[1:len(args)-len(result.Args)]...)
+		script, err := usecase.HookScript(result.Args[1], command)
+		if err != nil {
+			return err
+		}
+		_, _ = os.Stdout.WriteString(script)
+		return nil
+	}
+
+	// -p a,b,c or --each-profile runs the command once for each profile
+	var profiles []string
+	eachProfile := result.Options["each-profile"].IsSet()
+	if strings.Contains(profile, ",") {
+		for _, p := range strings.Split(profile, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	if eachProfile || len(profiles) > 0 {
+		switch {
+		case eachProfile && profile != "":
+			return goerr.New("--each-profile and --profile cannot be used together")
+		case subcommand != "":
+			return goerr.New("running across profiles cannot be used with a subcommand", goerr.V("subcommand", subcommand))
+		case result.Options["watch"].IsSet():
+			return goerr.New("--watch cannot be used when running across profiles")
+		case result.Options["report"].IsSet():
+			return goerr.New("--report cannot be used when running across profiles")
+		}
+	}
+
+	// --secret KEY=value is the same as the inline form KEY:=value
+	var secretArgs []string
+	for _, kv := range result.Options["secret"].StringSlice() {
+		name, value, found := strings.Cut(kv, "=")
+		if !found || name == "" {
+			return goerr.New("invalid --secret option (expected KEY=value)", goerr.V("name", name))
+		}
+		secretArgs = append(secretArgs, name+":="+value)
+	}
+	commandArgs = append(secretArgs, commandArgs...)
+
+	chdir := result.Options["chdir"].String()
+	var discoverDir string
+	if result.Options["discover-from-chdir"].IsSet() && chdir != "" {
+		abs, err := filepath.Abs(chdir)
+		if err != nil {
+			return goerr.Wrap(err, "invalid --chdir option", goerr.V("dir", chdir))
+		}
+		discoverDir = abs
+	}
+
+	var execOpts []executor.Option
+	flushDelay, err := time.ParseDuration(result.Options["redact-flush-delay"].String())
+	if err != nil {
+		return goerr.Wrap(err, "invalid --redact-flush-delay option")
+	}
+	execOpts = append(execOpts, executor.WithFlushDelay(flushDelay))
+	killGrace, err := time.ParseDuration(result.Options["kill-grace"].String())
+	if err != nil {
+		return goerr.Wrap(err, "invalid --kill-grace option")
+	}
+	execOpts = append(execOpts, executor.WithKillGrace(killGrace))
+	// Only the command's output is logged, not that of hooks
+	var outputLogOpts []executor.Option
+	if path := result.Options["output-log"].String(); path != "" {
+		outputLog := executor.OutputLog{
+			Timestamps: result.Options["output-log-timestamps"].IsSet(),
+			Tags:       result.Options["output-log-tags"].IsSet(),
+		}
+		switch format := result.Options["output-log-format"].String(); format {
+		case "text":
+		case "jsonl":
+			outputLog.JSON = true
+		default:
+			return goerr.New("invalid --output-log-format option, must be text or jsonl", goerr.V("format", format))
+		}
+
+		f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return goerr.Wrap(err, "failed to open output log", goerr.V("path", path))
+		}
+		defer func() { _ = f.Close() }()
+		outputLog.Writer = f
+		outputLogOpts = append(outputLogOpts, executor.WithOutputLog(outputLog))
+	}
+	var timeout time.Duration
+	if result.Options["timeout"].IsSet() {
+		timeout, err = time.ParseDuration(result.Options["timeout"].String())
+		if err != nil || timeout <= 0 {
+			return goerr.New("invalid --timeout option", goerr.V("timeout", result.Options["timeout"].String()))
+		}
+	}
+	retryPolicy, err := parseRetryPolicy(result)
+	if err != nil {
+		return err
+	}
+	watchDebounce, err := time.ParseDuration(result.Options["watch-debounce"].String())
+	if err != nil {
+		return goerr.Wrap(err, "invalid --watch-debounce option")
+	}
+	execMode := defaultExecMode
+	switch {
+	case result.Options["exec"].IsSet() && result.Options["no-exec"].IsSet():
+		return goerr.New("--exec and --no-exec cannot be used together")
+	case result.Options["exec"].IsSet():
+		execMode = executor.ExecPreferred
+	case result.Options["no-exec"].IsSet():
+		execMode = executor.ExecSpawn
+	}
+	execOpts = append(execOpts, executor.WithExecMode(execMode))
+	// An interactive shell needs a terminal even when its output is redacted
+	execOpts = append(execOpts, executor.WithPTY(result.Options["pty"].IsSet() || subcommand == "shell"))
+	// The usecase passes the filtered system environment explicitly
+	execOpts = append(execOpts, executor.WithInheritEnv(false))
+	if result.Options["redact-encoding"].IsSet() {
+		encodings, err := executor.ParseEncodings(result.Options["redact-encoding"].StringSlice())
+		if err != nil {
+			return goerr.Wrap(err, "invalid --redact-encoding option")
+		}
+		execOpts = append(execOpts, executor.WithRedactEncodings(encodings...))
+	}
+
+	var classifier *usecase.SecretClassifier
+	if !result.Options["no-auto-secret"].IsSet() {
+		entropy, err := strconv.ParseFloat(result.Options["secret-entropy"].String(), 64)
+		if err != nil {
+			return goerr.Wrap(err, "invalid --secret-entropy option")
+		}
+		classifier = usecase.NewSecretClassifier()
+		classifier.NamePatterns = append(classifier.NamePatterns, result.Options["secret-pattern"].StringSlice()...)
+		classifier.EntropyThreshold = entropy
+	}
+
 	// Create logger based on log-level flag
 	level := ParseLogLevel(logLevel)
-	logger := NewLogger(level, os.Stderr)
+	logger := NewLogger(level, os.Stderr, secrets)
+
+	reportPath := result.Options["report"].String()
+	var report *model.Report
+	if reportPath != "" {
+		report = &model.Report{Profile: result.Options["profile"].String()}
+		logger = slog.New(newReportHandler(logger.Handler(), report, secrets))
+	}
 
 	// Set logger in context for propagation
 	ctx = ctxlog.With(ctx, logger)
 
-	// Collect environment variables in order for YAML loader reference
-	var allExistingVars []*model.EnvVar
-
-	// First, collect system environment variables
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) == 2 {
-			allExistingVars = append(allExistingVars, &model.EnvVar{
-				Name:   parts[0],
-				Value:  parts[1],
-				Source: model.SourceSystem,
-			})
+	// .env files, loaded before config files so that these can refer to their variables
+	if len(envFiles) == 0 {
+		path := loader.ResolveDefaultDotEnvPath()
+		if discoverDir != "" {
+			path = loader.ResolveDefaultDotEnvPathFrom(discoverDir)
 		}
+		envFiles = []string{path}
 	}
-
-	// Load .env files once and collect their variables
 	var envLoaders []loader.LoadFunc
 	for _, envFile := range envFiles {
 		envLoaders = append(envLoaders, loader.NewDotEnvLoader(envFile))
 	}
-	if len(envFiles) == 0 {
-		envLoaders = append(envLoaders, loader.NewDotEnvLoader(loader.ResolveDefaultDotEnvPath()))
-	}
 
-	// Execute .env loaders once and collect results
-	var loadedDotEnvVars []*model.EnvVar
-	for _, loadFunc := range envLoaders {
-		envVars, err := loadFunc(ctx)
-		if err != nil {
-			return goerr.Wrap(err, "failed to load .env file")
+	// Resolve config paths (HCL or YAML, picked by extension)
+	if len(configFiles) == 0 {
+		// Default path resolution: prefer .env.hcl if present (do not merge with YAML).
+		hclPath, yamlPath := loader.FindDefaultHCLPath(), loader.ResolveDefaultYAMLPath()
+		if discoverDir != "" {
+			hclPath, yamlPath = loader.FindDefaultHCLPathFrom(discoverDir), loader.ResolveDefaultYAMLPathFrom(discoverDir)
+		}
+		if hclPath != "" {
+			configFiles = []string{hclPath}
+		} else {
+			configFiles = []string{yamlPath}
 		}
-		if envVars != nil {
-			loadedDotEnvVars = append(loadedDotEnvVars, envVars...)
+	}
+
+	// Config files that run commands must be allowed first
+	if subcommand == "allow" || subcommand == "deny" {
+		return updateTrust(ctx, subcommand, result.Args[1:], configFiles)
+	}
+	trusted := result.Options["trust"].IsSet()
+	if !trusted {
+		if err := checkTrust(ctx, configFiles); err != nil {
+			return err
 		}
 	}
-	allExistingVars = append(allExistingVars, loadedDotEnvVars...)
 
-	// Now create config loaders (HCL or YAML, picked by extension) with profile and existing vars
-	var configLoaders []loader.LoadFunc
+	// Settings declared in config files; later files override earlier ones
+	settings := &model.Settings{}
 	for _, configFile := range configFiles {
-		configLoaders = append(configLoaders, newConfigLoader(configFile, profile, allExistingVars))
+		fileSettings, err := loader.LoadSettings(ctx, configFile)
+		if err != nil {
+			return goerr.Wrap(err, "failed to load config settings", goerr.V("path", configFile))
+		}
+		settings.Merge(fileSettings)
 	}
-	if len(configFiles) == 0 {
-		// Default path resolution: prefer .env.hcl if present (do not merge with YAML).
-		if hclPath := loader.FindDefaultHCLPath(); hclPath != "" {
-			configLoaders = append(configLoaders, loader.NewHCLLoaderWithProfile(hclPath, profile, allExistingVars))
-		} else {
-			configLoaders = append(configLoaders, loader.NewYAMLLoaderWithProfile(loader.ResolveDefaultYAMLPath(), profile, allExistingVars))
+
+	maskName := settings.Mask
+	if result.Options["mask"].IsSet() {
+		maskName = result.Options["mask"].String()
+	}
+	maskPolicy, err := redact.ParsePolicy(maskName)
+	if err != nil {
+		return goerr.Wrap(err, "invalid mask policy")
+	}
+	execOpts = append(execOpts, executor.WithMaskPolicy(maskPolicy))
+
+	// -C takes precedence over the workdir declared in config
+	workdir := settings.Workdir
+	if chdir != "" {
+		workdir = chdir
+	}
+	if workdir != "" {
+		if info, err := os.Stat(workdir); err != nil || !info.IsDir() {
+			return goerr.New("working directory does not exist", goerr.V("dir", workdir))
 		}
+		execOpts = append(execOpts, executor.WithDir(workdir))
 	}
 
-	// Combine all loaders for the usecase
-	var loaders []loader.LoadFunc
-	// Use an in-memory loader for .env vars to avoid reading files twice
-	loaders = append(loaders, func(ctx context.Context) ([]*model.EnvVar, error) {
-		return loadedDotEnvVars, nil
-	})
-	loaders = append(loaders, configLoaders...)
+	// Config loaders take the system environment and the .env variables for
+	// reference, so all files are loaded in one pass that watch mode can repeat.
+	// Files changed since the check above must be allowed again.
+	newLoaders := func(profile string) []loader.LoadFunc {
+		return []loader.LoadFunc{func(ctx context.Context) ([]*model.EnvVar, error) {
+			if !trusted {
+				if err := checkTrust(ctx, configFiles); err != nil {
+					return nil, err
+				}
+			}
+
+			var loadedDotEnvVars []*model.EnvVar
+			for _, loadFunc := range envLoaders {
+				envVars, err := loadFunc(ctx)
+				if err != nil {
+					return nil, goerr.Wrap(err, "failed to load .env file")
+				}
+				loadedDotEnvVars = append(loadedDotEnvVars, envVars...)
+			}
+
+			allExistingVars := append(systemEnvVars(), loadedDotEnvVars...)
+			loaded := loadedDotEnvVars
+			for _, configFile := range configFiles {
+				envVars, err := newConfigLoader(configFile, profile, allExistingVars)(ctx)
+				if err != nil {
+					return nil, err
+				}
+				loaded = append(loaded, envVars...)
+			}
+			return loaded, nil
+		}}
+	}
+
+	// Create executor and usecase. Hooks run without the output log, timeout
+	// and retry of the command.
+	exec := executor.NewDefaultExecutor(append(slices.Clone(execOpts), outputLogOpts...)...)
+	if timeout > 0 {
+		exec = executor.NewTimeoutExecutor(exec, timeout)
+	}
+	exec = executor.NewRetryExecutor(exec, retryPolicy)
+	hookExec := executor.NewDefaultExecutor(execOpts...)
+	newUseCase := func(profile string) *usecase.UseCase {
+		uc := usecase.NewUseCase(newLoaders(profile), exec)
+		uc.HookExecutor = hookExec
+		uc.EnableTemplate = enableTemplate
+		uc.AllowSecretArgs = result.Options["allow-secret-args"].IsSet()
+		uc.Secrets = secrets
+		uc.MaskPolicy = maskPolicy
+		uc.IgnoreEnvironment = result.Options["ignore-environment"].IsSet()
+		uc.Unset = result.Options["unset"].StringSlice()
+		uc.Passthrough = settings.Passthrough
+		uc.Hooks = settings.Hooks
+		uc.Classifier = classifier
+		uc.Watch = result.Options["watch"].IsSet()
+		uc.WatchDebounce = watchDebounce
+		uc.Report = report
+		uc.ReportValues = result.Options["report-values"].IsSet()
+		if report != nil {
+			report.Profile = profile
+		}
+		return uc
+	}
+	runner := &usecase.TaskRunner{
+		Tasks:      settings.Tasks,
+		Profile:    profile,
+		NewUseCase: newUseCase,
+	}
 
-	// Create executor and usecase
-	exec := executor.NewDefaultExecutor()
-	uc := usecase.NewUseCase(loaders, exec)
-	uc.EnableTemplate = enableTemplate
+	if eachProfile {
+		for _, configFile := range configFiles {
+			fileProfiles, err := loader.ListProfiles(ctx, configFile)
+			if err != nil {
+				return goerr.Wrap(err, "failed to list profiles", goerr.V("path", configFile))
+			}
+			for _, p := range fileProfiles {
+				if !slices.Contains(profiles, p) {
+					profiles = append(profiles, p)
+				}
+			}
+		}
+		if len(profiles) == 0 {
+			return goerr.New("no profiles are defined in the configuration")
+		}
+		sort.Strings(profiles)
+	}
 
 	// If no command specified, force list mode
 	if len(commandArgs) == 0 {
 		commandArgs = []string{} // Force empty args to show environment variables
 	}
 
-	if err := uc.Run(ctx, commandArgs); err != nil {
+	switch subcommand {
+	case "tasks":
+		if err := settings.Tasks.Validate(); err != nil {
+			return goerr.Wrap(err, "invalid tasks")
+		}
+		runner.List(os.Stdout)
+		return nil
+
+	case "run":
+		if len(result.Args) < 2 {
+			return goerr.New("task name is required: zenv run <task> [args...]")
+		}
+		if err := settings.Tasks.Validate(); err != nil {
+			return goerr.Wrap(err, "invalid tasks")
+		}
+		err = runner.Run(ctx, result.Args[1], secretArgs, result.Args[2:])
+
+	case "shell":
+		if len(result.Args) > 1 {
+			return goerr.New("too many arguments: zenv shell")
+		}
+		err = newUseCase(profile).Shell(ctx, secretArgs, profile)
+
+	case "export":
+		if len(result.Args) != 2 {
+			return goerr.New("shell is required: zenv export bash|zsh|fish")
+		}
+		err = newUseCase(profile).Export(ctx, result.Args[1], append(slices.Clone(envFiles), configFiles...), os.Stdout)
+
+	case "start":
+		err = startProcfile(ctx, newUseCase(profile), result, secretArgs)
+
+	default:
+		if len(profiles) > 0 {
+			profileRunner := &usecase.ProfileRunner{
+				Profiles:   profiles,
+				Parallel:   result.Options["parallel"].IsSet(),
+				NewUseCase: newUseCase,
+				Color:      useColor(),
+			}
+			err = profileRunner.Run(ctx, commandArgs)
+			break
+		}
+		err = newUseCase(profile).Run(ctx, commandArgs)
+	}
+	if report != nil {
+		report.SetResult(secrets.ScrubError(err))
+		if writeErr := writeReport(reportPath, report); writeErr != nil {
+			if err == nil {
+				return writeErr
+			}
+			logger.Warn("failed to write report", "error", writeErr)
+		}
+	}
+	return err
+}
+
+// startProcfile runs the processes of the Procfile given after "start", or of
+// DefaultProcfile
+func startProcfile(ctx context.Context, uc *usecase.UseCase, result *ParseResult, secretArgs []string) error {
+	if len(result.Args) > 2 {
+		return goerr.New("too many arguments: zenv start [Procfile]")
+	}
+	path := loader.DefaultProcfile
+	if len(result.Args) == 2 {
+		path = result.Args[1]
+	}
+	procs, err := loader.LoadProcfile(path)
+	if err != nil {
+		return err
+	}
+
+	onExit, err := usecase.ParseOnExit(result.Options["on-exit"].String())
+	if err != nil {
+		return err
+	}
+	return uc.Start(ctx, secretArgs, procs, usecase.StartOptions{
+		OnExit: onExit,
+		Color:  useColor(),
+	})
+}
+
+// useColor reports whether output prefixes are coloured: on a terminal,
+// unless NO_COLOR is set
+func useColor() bool {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return !noColor && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// parseRetryPolicy builds the retry policy from the --retry options
+func parseRetryPolicy(result *ParseResult) (executor.RetryPolicy, error) {
+	var policy executor.RetryPolicy
+
+	retries, err := strconv.Atoi(result.Options["retry"].String())
+	if err != nil || retries < 0 {
+		return policy, goerr.New("invalid --retry option", goerr.V("retry", result.Options["retry"].String()))
+	}
+	policy.Retries = retries
+
+	policy.Delay, err = time.ParseDuration(result.Options["retry-delay"].String())
+	if err != nil {
+		return policy, goerr.Wrap(err, "invalid --retry-delay option")
+	}
+
+	policy.Backoff, err = strconv.ParseFloat(result.Options["retry-backoff"].String(), 64)
+	if err != nil || policy.Backoff < 1 {
+		return policy, goerr.New("invalid --retry-backoff option, must be 1 or more", goerr.V("retry-backoff", result.Options["retry-backoff"].String()))
+	}
+
+	for _, value := range result.Options["retry-on-exit-codes"].StringSlice() {
+		for _, s := range strings.Split(value, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return policy, goerr.Wrap(err, "invalid --retry-on-exit-codes option", goerr.V("code", s))
+			}
+			policy.ExitCodes = append(policy.ExitCodes, code)
+		}
+	}
+	return policy, nil
+}
+
+// systemEnvVars returns zenv's own environment as variables
+func systemEnvVars() []*model.EnvVar {
+	var envVars []*model.EnvVar
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			envVars = append(envVars, &model.EnvVar{
+				Name:   parts[0],
+				Value:  parts[1],
+				Source: model.SourceSystem,
+			})
+		}
+	}
+	return envVars
+}
+
+// newTrustStore opens the store of allowed configuration files
+func newTrustStore() (*trust.Store, error) {
+	path, err := trust.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return trust.NewStore(path), nil
+}
+
+// checkTrust fails unless every config file that runs commands is allowed
+func checkTrust(ctx context.Context, configFiles []string) error {
+	var store *trust.Store
+	for _, configFile := range configFiles {
+		files, err := loader.CommandFiles(ctx, configFile)
+		if err != nil {
+			return goerr.Wrap(err, "failed to load config file", goerr.V("path", configFile))
+		}
+		for _, file := range files {
+			if store == nil {
+				if store, err = newTrustStore(); err != nil {
+					return err
+				}
+			}
+			if err := store.Check(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateTrust allows or denies files, or the discovered config files that
+// run commands when no file is given
+func updateTrust(ctx context.Context, subcommand string, files, configFiles []string) error {
+	if len(files) == 0 {
+		for _, configFile := range configFiles {
+			commandFiles, err := loader.CommandFiles(ctx, configFile)
+			if err != nil {
+				return goerr.Wrap(err, "failed to load config file", goerr.V("path", configFile))
+			}
+			files = append(files, commandFiles...)
+		}
+		if len(files) == 0 {
+			_, _ = os.Stderr.WriteString("zenv: no configuration file runs commands\n")
+			return nil
+		}
+	}
+
+	store, err := newTrustStore()
+	if err != nil {
 		return err
 	}
+	for _, file := range files {
+		if subcommand == "allow" {
+			if err := store.Allow(file); err != nil {
+				return err
+			}
+			_, _ = os.Stderr.WriteString("zenv: allowed " + file + "\n")
+			continue
+		}
+		if _, err := store.Deny(file); err != nil {
+			return err
+		}
+		_, _ = os.Stderr.WriteString("zenv: denied " + file + "\n")
+	}
 	return nil
 }
@@ -2,12 +2,18 @@ package cli_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/m-mizutani/gt"
 	"github.com/m-mizutani/zenv/v2/pkg/cli"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
 )
 
 func TestCLI(t *testing.T) {
@@ -275,4 +281,576 @@ FROM_HCL:
 
 		gt.NoError(t, err)
 	})
+
+	t.Run("Reject invalid --redact-flush-delay", func(t *testing.T) {
+		args := []string{"zenv", "--redact-flush-delay", "soon", "true"}
+		err := cli.Run(context.Background(), args)
+
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("invalid --redact-flush-delay option")
+	})
+
+	t.Run("Secrets are masked in debug logs and errors", func(t *testing.T) {
+		tmpFile := gt.R1(os.CreateTemp("", "test*.yaml")).NoError(t)
+		defer os.Remove(tmpFile.Name())
+
+		content := `DB_PASSWORD:
+  value: "hunter2-secret"
+  secret: true
+`
+		gt.R1(tmpFile.WriteString(content)).NoError(t)
+		tmpFile.Close()
+
+		r, w, _ := os.Pipe()
+		oldStderr := os.Stderr
+		os.Stderr = w
+
+		args := []string{"zenv", "-l", "debug", "-c", tmpFile.Name(), "no-such-command-hunter2-secret"}
+		err := cli.Run(context.Background(), args)
+
+		w.Close()
+		os.Stderr = oldStderr
+		logs := gt.R1(io.ReadAll(r)).NoError(t)
+
+		gt.Error(t, err)
+		gt.S(t, err.Error()).NotContains("hunter2-secret")
+		gt.S(t, err.Error()).Contains("no-such-command-*****")
+		// Arguments logged before variables are resolved can't be known as
+		// secrets yet; everything logged after loading must be masked.
+		_, afterLoad, found := strings.Cut(string(logs), "loaded environment variables")
+		gt.True(t, found)
+		gt.S(t, afterLoad).Contains(`"command":"no-such-command-*****"`)
+		gt.S(t, afterLoad).NotContains("hunter2-secret")
+	})
+
+	t.Run("Mask inline secrets in debug logs", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		oldStderr := os.Stderr
+		os.Stderr = w
+
+		args := []string{"zenv", "-l", "debug", "-e", filepath.Join(t.TempDir(), "none.env"),
+			"-s", "FOO=hunter2xyz", "BAR:=barsecret99", "true"}
+		err := cli.Run(context.Background(), args)
+
+		w.Close()
+		os.Stderr = oldStderr
+		logs := string(gt.R1(io.ReadAll(r)).NoError(t))
+
+		gt.NoError(t, err)
+		gt.S(t, logs).Contains("starting zenv run")
+		gt.S(t, logs).NotContains("hunter2xyz")
+		gt.S(t, logs).NotContains("barsecret99")
+	})
+
+	t.Run("Auto secret classification and --no-auto-secret", func(t *testing.T) {
+		tmpFile := gt.R1(os.CreateTemp("", "test*.env")).NoError(t)
+		defer os.Remove(tmpFile.Name())
+
+		gt.R1(tmpFile.WriteString("SERVICE_TOKEN=tok-value-123\nSENTRY_DSN=https://dsn.example\n")).NoError(t)
+		tmpFile.Close()
+
+		list := func(extra ...string) string {
+			r, w, _ := os.Pipe()
+			oldStdout := os.Stdout
+			os.Stdout = w
+
+			args := append([]string{"zenv", "-e", tmpFile.Name()}, extra...)
+			err := cli.Run(context.Background(), args)
+
+			w.Close()
+			os.Stdout = oldStdout
+			gt.NoError(t, err)
+			return string(gt.R1(io.ReadAll(r)).NoError(t))
+		}
+
+		output := list("--secret-pattern", "*_DSN")
+		gt.S(t, output).NotContains("tok-value-123")
+		gt.S(t, output).Contains("(secret: name matches *_TOKEN)")
+		gt.S(t, output).Contains("(secret: name matches *_DSN)")
+
+		output = list("--no-auto-secret")
+		gt.S(t, output).Contains("SERVICE_TOKEN=tok-value-123 [.env]")
+	})
+
+	t.Run("Run with --secret option", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		oldStdout := os.Stdout
+		os.Stdout = w
+
+		args := []string{"zenv", "--no-auto-secret", "--secret", "MY_PASS=p@ss", "-s", "OTHER=x=y", "PLAIN:=inline"}
+		err := cli.Run(context.Background(), args)
+
+		w.Close()
+		os.Stdout = oldStdout
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+
+		gt.NoError(t, err)
+		gt.S(t, output).Contains("MY_PASS=***** [inline] (secret: declared)")
+		gt.S(t, output).Contains("OTHER=***** [inline] (secret: declared)")
+		gt.S(t, output).Contains("PLAIN=***** [inline] (secret: declared)")
+		gt.S(t, output).NotContains("p@ss")
+	})
+
+	t.Run("Reject --secret without value", func(t *testing.T) {
+		err := cli.Run(context.Background(), []string{"zenv", "--secret", "NOVALUE"})
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("invalid --secret option")
+	})
+
+	t.Run("Mask policy from option and config", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, ".env.yaml")
+		gt.NoError(t, os.WriteFile(configPath, []byte("mask: name\nDB_PASS:\n  value: hunter2-pass\n  secret: true\n"), 0o600))
+
+		list := func(extra ...string) string {
+			r, w, _ := os.Pipe()
+			oldStdout := os.Stdout
+			os.Stdout = w
+
+			args := append([]string{"zenv", "--no-auto-secret", "-c", configPath}, extra...)
+			err := cli.Run(context.Background(), args)
+
+			w.Close()
+			os.Stdout = oldStdout
+			gt.NoError(t, err)
+			return string(gt.R1(io.ReadAll(r)).NoError(t))
+		}
+
+		gt.S(t, list()).Contains("DB_PASS=[REDACTED:DB_PASS] [.yaml]")
+		gt.S(t, list("--mask", "fixed")).Contains("DB_PASS=***** [.yaml]")
+		gt.S(t, list("--mask", "hash")).Contains("DB_PASS=[sha256:")
+	})
+
+	t.Run("Reject unknown mask policy", func(t *testing.T) {
+		err := cli.Run(context.Background(), []string{"zenv", "--mask", "stars"})
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("invalid mask policy")
+	})
+
+	t.Run("Reject invalid --kill-grace", func(t *testing.T) {
+		err := cli.Run(context.Background(), []string{"zenv", "--kill-grace", "soon", "true"})
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("invalid --kill-grace option")
+	})
+
+	t.Run("Watch reloads .env files", func(t *testing.T) {
+		dir := t.TempDir()
+		envPath := filepath.Join(dir, "watch.env")
+		outPath := filepath.Join(dir, "out")
+		gt.NoError(t, os.WriteFile(envPath, []byte("FOO=1\n"), 0600))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			done <- cli.Run(ctx, []string{"zenv", "-e", envPath, "--watch", "--watch-debounce", "50ms", "--kill-grace", "1s",
+				"sh", "-c", `echo "$FOO" >> "$0"; exec sleep 30`, outPath})
+		}()
+
+		waitForOutput := func(want string) {
+			t.Helper()
+			deadline := time.Now().Add(5 * time.Second)
+			for time.Now().Before(deadline) {
+				if data, _ := os.ReadFile(outPath); string(data) == want {
+					return
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			data, _ := os.ReadFile(outPath)
+			t.Fatalf("output = %q, want %q", data, want)
+		}
+
+		waitForOutput("1\n")
+		gt.NoError(t, os.WriteFile(envPath, []byte("FOO=22\n"), 0600))
+		waitForOutput("1\n22\n")
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch did not stop after cancellation")
+		}
+	})
+
+	t.Run("Timeout and retry", func(t *testing.T) {
+		err := cli.Run(context.Background(), []string{"zenv", "--timeout", "100ms", "sleep", "5"})
+		gt.Equal(t, model.GetExitCode(err), model.TimeoutExitCode)
+
+		counter := filepath.Join(t.TempDir(), "count")
+		err = cli.Run(context.Background(), []string{"zenv", "--retry", "2", "--retry-delay", "1ms", "--retry-on-exit-codes", "3,4",
+			"sh", "-c", `echo x >> "$0"; exit 3`, counter})
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.Equal(t, string(gt.R1(os.ReadFile(counter)).NoError(t)), "x\nx\nx\n")
+
+		for _, args := range [][]string{
+			{"--timeout", "0"},
+			{"--retry", "-1"},
+			{"--retry-backoff", "0.5"},
+			{"--retry-on-exit-codes", "any"},
+		} {
+			gt.Error(t, cli.Run(context.Background(), append(append([]string{"zenv"}, args...), "true")))
+		}
+	})
+
+	t.Run("Write redacted output log", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "output.log")
+		err := cli.Run(context.Background(), []string{"zenv", "--output-log", logPath, "--output-log-tags",
+			"-s", "TOKEN=my-secret-123", "sh", "-c", `echo "token=$TOKEN"; echo oops >&2`})
+		gt.NoError(t, err)
+
+		output := string(gt.R1(os.ReadFile(logPath)).NoError(t))
+		gt.S(t, output).Contains("[out] token=*****\n")
+		gt.S(t, output).Contains("[err] oops\n")
+		gt.S(t, output).NotContains("my-secret-123")
+
+		err = cli.Run(context.Background(), []string{"zenv", "--output-log", logPath, "--output-log-format", "xml", "true"})
+		gt.Error(t, err)
+	})
+
+	t.Run("Write execution report", func(t *testing.T) {
+		dir := t.TempDir()
+		envPath := filepath.Join(dir, "report.env")
+		reportPath := filepath.Join(dir, "report.json")
+		gt.NoError(t, os.WriteFile(envPath, []byte("PUBLIC=visible\n"), 0600))
+
+		err := cli.Run(context.Background(), []string{"zenv", "-e", envPath, "--report", reportPath, "--report-values", "--exec",
+			"-s", "TOKEN=my-secret-123", "sh", "-c", "exit 3"})
+		gt.Equal(t, model.GetExitCode(err), 3)
+
+		data := gt.R1(os.ReadFile(reportPath)).NoError(t)
+		gt.S(t, string(data)).NotContains("my-secret-123")
+
+		var report model.Report
+		gt.NoError(t, json.Unmarshal(data, &report))
+		gt.A(t, report.Files).Has(envPath)
+		gt.Equal(t, report.Command, "sh")
+		gt.Equal(t, report.Args, []string{"-c", "exit 3"})
+		gt.Equal(t, report.ExitCode, 3)
+		gt.False(t, report.StartedAt.IsZero())
+		gt.True(t, !report.EndedAt.Before(report.StartedAt))
+		gt.Equal(t, report.Variables[".env"], []model.ReportVariable{{Name: "PUBLIC", Value: &[]string{"visible"}[0]}})
+		gt.Equal(t, report.Variables["inline"], []model.ReportVariable{{Name: "TOKEN", Secret: true}})
+		// --exec falls back to a child process to finish the report
+		gt.A(t, report.Warnings).Length(1)
+		gt.S(t, report.Warnings[0]).Contains("reason=report")
+
+		// Values are left out unless requested
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "-e", envPath, "--report", reportPath, "true"}))
+		gt.S(t, string(gt.R1(os.ReadFile(reportPath)).NoError(t))).NotContains("visible")
+	})
+
+	t.Run("Run and list tasks", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "tasks.yaml")
+		outPath := filepath.Join(dir, "out")
+		config := `GREETING: hello
+tasks:
+  prepare:
+    command: ["sh", "-c", "echo prepared >> ` + outPath + `"]
+  greet:
+    description: Print a greeting
+    command: ["sh", "-c", "echo \"$GREETING $NAME $1\" >> ` + outPath + `", "greet"]
+    env:
+      NAME: zenv
+    depends: [prepare]
+`
+		gt.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+		noEnv := filepath.Join(dir, "none.env")
+
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "run", "greet", "world"}))
+		gt.Equal(t, string(gt.R1(os.ReadFile(outPath)).NoError(t)), "prepared\nhello zenv world\n")
+
+		r, w, _ := os.Pipe()
+		oldStdout := os.Stdout
+		os.Stdout = w
+		err := cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "tasks"})
+		w.Close()
+		os.Stdout = oldStdout
+		gt.NoError(t, err)
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+		gt.S(t, output).Contains("greet    Print a greeting (depends on prepare)")
+		gt.S(t, output).Contains("prepare")
+
+		err = cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "run", "missing"})
+		gt.Error(t, err)
+	})
+
+	t.Run("Run hooks from config", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "hooks.yaml")
+		outPath := filepath.Join(dir, "out")
+		config := `hooks:
+  before:
+    - command: ["sh", "-c", "echo SESSION=abc"]
+      export: true
+  after:
+    - command: ["sh", "-c", "echo cleanup >> ` + outPath + `"]
+`
+		gt.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+		args := []string{"zenv", "--trust", "-e", filepath.Join(dir, "none.env"), "-c", configPath, "sh", "-c", "echo \"session $SESSION\" >> " + outPath}
+		gt.NoError(t, cli.Run(context.Background(), args))
+		gt.Equal(t, string(gt.R1(os.ReadFile(outPath)).NoError(t)), "session abc\ncleanup\n")
+	})
+
+	t.Run("Hooks are not retried or logged as output", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "hooks.yaml")
+		counter := filepath.Join(dir, "count")
+		logPath := filepath.Join(dir, "output.log")
+		config := `hooks:
+  before:
+    - command: ["sh", "-c", "echo hook-output; echo x >> ` + counter + `; exit 3"]
+`
+		gt.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+		args := []string{"zenv", "--trust", "--retry", "2", "--retry-delay", "1ms", "--output-log", logPath,
+			"-e", filepath.Join(dir, "none.env"), "-c", configPath, "true"}
+		err := cli.Run(context.Background(), args)
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.Equal(t, string(gt.R1(os.ReadFile(counter)).NoError(t)), "x\n")
+		gt.S(t, string(gt.R1(os.ReadFile(logPath)).NoError(t))).NotContains("hook-output")
+	})
+
+	t.Run("Require approval of config running commands", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+		configPath := filepath.Join(dir, "command.yaml")
+		gt.NoError(t, os.WriteFile(configPath, []byte("TOKEN:\n  command: [\"echo\", \"abc\"]\n"), 0600))
+		noEnv := filepath.Join(dir, "none.env")
+		args := []string{"zenv", "-e", noEnv, "-c", configPath, "true"}
+
+		err := cli.Run(context.Background(), args)
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("zenv allow")
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "--trust", "-e", noEnv, "-c", configPath, "true"}))
+
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "allow"}))
+		gt.NoError(t, cli.Run(context.Background(), args))
+
+		// A change requires approval again
+		gt.NoError(t, os.WriteFile(configPath, []byte("TOKEN:\n  command: [\"echo\", \"xyz\"]\n"), 0600))
+		gt.Error(t, cli.Run(context.Background(), args))
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "allow", configPath}))
+		gt.NoError(t, cli.Run(context.Background(), args))
+
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "deny"}))
+		gt.Error(t, cli.Run(context.Background(), args))
+	})
+
+	t.Run("Watch refuses a config changed to run commands", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+		configPath := filepath.Join(dir, "watch.yaml")
+		outPath := filepath.Join(dir, "out")
+		pwned := filepath.Join(dir, "pwned")
+		gt.NoError(t, os.WriteFile(configPath, []byte("FOO: \"1\"\n"), 0600))
+
+		r, w, _ := os.Pipe()
+		oldStderr := os.Stderr
+		os.Stderr = w
+		defer func() { os.Stderr = oldStderr }()
+		refused := make(chan struct{})
+		go func() {
+			var buf strings.Builder
+			chunk := make([]byte, 1024)
+			for {
+				n, err := r.Read(chunk)
+				buf.Write(chunk[:n])
+				if strings.Contains(buf.String(), "not allowed") {
+					close(refused)
+					_, _ = io.Copy(io.Discard, r)
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			done <- cli.Run(ctx, []string{"zenv", "-e", filepath.Join(dir, "none.env"), "-c", configPath,
+				"--watch", "--watch-debounce", "50ms", "--kill-grace", "1s",
+				"sh", "-c", `echo "$FOO" >> "$0"; exec sleep 30`, outPath})
+		}()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(outPath); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		config := "FOO: \"2\"\nEVIL:\n  command: [\"touch\", \"" + pwned + "\"]\n"
+		gt.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+		select {
+		case <-refused:
+		case <-time.After(5 * time.Second):
+			t.Fatal("changed config was not refused")
+		}
+		_, err := os.Stat(pwned)
+		gt.True(t, os.IsNotExist(err))
+		gt.Equal(t, string(gt.R1(os.ReadFile(outPath)).NoError(t)), "1\n")
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch did not stop after cancellation")
+		}
+		w.Close()
+	})
+
+	t.Run("Run across profiles", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "profiles.yaml")
+		config := `HOST:
+  value: localhost
+  profile:
+    dev: dev.local
+    prod: prod.example.com
+`
+		gt.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+		noEnv := filepath.Join(dir, "none.env")
+
+		run := func(extra ...string) (string, error) {
+			r, w, _ := os.Pipe()
+			oldStdout := os.Stdout
+			os.Stdout = w
+			args := append([]string{"zenv", "-e", noEnv, "-c", configPath}, extra...)
+			args = append(args, "sh", "-c", `echo "$HOST"; [ "$HOST" != localhost ]`)
+			err := cli.Run(context.Background(), args)
+			w.Close()
+			os.Stdout = oldStdout
+			return string(gt.R1(io.ReadAll(r)).NoError(t)), err
+		}
+
+		output, err := run("--each-profile", "--parallel")
+		gt.NoError(t, err)
+		gt.S(t, output).Contains("dev  | dev.local\n").Contains("prod | prod.example.com\n")
+
+		// ci has no values of its own
+		output, err = run("-p", "dev,ci")
+		gt.Error(t, err)
+		gt.Equal(t, output, "dev | dev.local\nci  | localhost\n")
+
+		_, err = run("--each-profile", "-p", "dev")
+		gt.Error(t, err)
+	})
+
+	t.Run("Start a shell with markers", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "shell.yaml")
+		gt.NoError(t, os.WriteFile(configPath, []byte("HOST: dev.local\n"), 0600))
+		outPath := filepath.Join(dir, "out")
+		shell := filepath.Join(dir, "myshell")
+		gt.NoError(t, os.WriteFile(shell, []byte("#!/bin/sh\necho \"$ZENV_ACTIVE $ZENV_PROFILE $HOST\" > "+outPath+"\n"), 0o700))
+		t.Setenv("SHELL", shell)
+
+		gt.NoError(t, cli.Run(context.Background(), []string{"zenv", "-e", filepath.Join(dir, "none.env"), "-c", configPath, "-p", "dev", "shell"}))
+		gt.Equal(t, string(gt.R1(os.ReadFile(outPath)).NoError(t)), "1 dev dev.local\n")
+	})
+
+	t.Run("Print shell hook and export environment", func(t *testing.T) {
+		capture := func(args ...string) string {
+			r, w, _ := os.Pipe()
+			oldStdout := os.Stdout
+			os.Stdout = w
+			err := cli.Run(context.Background(), append([]string{"zenv"}, args...))
+			w.Close()
+			os.Stdout = oldStdout
+			gt.NoError(t, err)
+			return string(gt.R1(io.ReadAll(r)).NoError(t))
+		}
+
+		gt.S(t, capture("-p", "dev", "hook", "zsh")).Contains(`'-p' 'dev' 'export' 'zsh'`)
+
+		envPath := filepath.Join(t.TempDir(), "hook.env")
+		gt.NoError(t, os.WriteFile(envPath, []byte("ZENV_HOOK_CLI=loaded\n"), 0600))
+		t.Setenv(usecase.HookStateEnvName, "")
+		output := capture("-e", envPath, "-c", filepath.Join(t.TempDir(), "none.yaml"), "export", "fish")
+		gt.S(t, output).Contains("set -gx ZENV_HOOK_CLI 'loaded';\n").Contains("set -gx " + usecase.HookStateEnvName + " ")
+
+		gt.Error(t, cli.Run(context.Background(), []string{"zenv", "hook"}))
+		gt.Error(t, cli.Run(context.Background(), []string{"zenv", "hook", "tcsh"}))
+	})
+
+	t.Run("Start processes from a Procfile", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "start.yaml")
+		gt.NoError(t, os.WriteFile(configPath, []byte("GREETING: hello\n"), 0600))
+		procfile := filepath.Join(dir, "Procfile")
+		gt.NoError(t, os.WriteFile(procfile, []byte("web: echo \"web $GREETING\"\nworker: sleep 0.2; echo worker; exit 4\n"), 0600))
+		noEnv := filepath.Join(dir, "none.env")
+
+		r, w, _ := os.Pipe()
+		oldStdout := os.Stdout
+		os.Stdout = w
+		err := cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "--on-exit", "continue", "start", procfile})
+		w.Close()
+		os.Stdout = oldStdout
+		gt.Error(t, err)
+		gt.Equal(t, model.GetExitCode(err), 4)
+
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+		gt.S(t, output).Contains("web    | web hello\n")
+		gt.S(t, output).Contains("worker | worker\n")
+
+		err = cli.Run(context.Background(), []string{"zenv", "-e", noEnv, "-c", configPath, "--on-exit", "never", "start", procfile})
+		gt.Error(t, err)
+	})
+
+	t.Run("Reject --exec with --no-exec", func(t *testing.T) {
+		err := cli.Run(context.Background(), []string{"zenv", "--exec", "--no-exec", "true"})
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("cannot be used together")
+	})
+
+	t.Run("Unset and ignore system environment", func(t *testing.T) {
+		t.Setenv("ZENV_TEST_SYSTEM", "from-system")
+		t.Setenv("ZENV_TEST_OTHER", "other")
+
+		list := func(extra ...string) string {
+			r, w, _ := os.Pipe()
+			oldStdout := os.Stdout
+			os.Stdout = w
+
+			args := append([]string{"zenv", "-e", filepath.Join(t.TempDir(), "none.env")}, extra...)
+			err := cli.Run(context.Background(), args)
+
+			w.Close()
+			os.Stdout = oldStdout
+			gt.NoError(t, err)
+			return string(gt.R1(io.ReadAll(r)).NoError(t))
+		}
+
+		output := list("-u", "ZENV_TEST_SYSTEM")
+		gt.S(t, output).NotContains("ZENV_TEST_SYSTEM")
+		gt.S(t, output).Contains("ZENV_TEST_OTHER=other [system]")
+
+		output = list("-i", "INLINE=1")
+		gt.S(t, output).NotContains("[system]")
+		gt.S(t, output).Contains("INLINE=1 [inline]")
+	})
+
+	t.Run("Run command in -C directory", func(t *testing.T) {
+		dir := gt.R1(filepath.EvalSymlinks(t.TempDir())).NoError(t)
+		gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("FROM_DIR=found\n"), 0600))
+		out := filepath.Join(t.TempDir(), "out")
+
+		args := []string{"zenv", "-e", filepath.Join(t.TempDir(), "none.env"), "-C", dir, "sh", "-c", `pwd -P > ` + out}
+		gt.NoError(t, cli.Run(context.Background(), args))
+		gt.Equal(t, string(gt.R1(os.ReadFile(out)).NoError(t)), dir+"\n")
+
+		args = []string{"zenv", "-C", dir, "--discover-from-chdir", "sh", "-c", `echo "$FROM_DIR" > ` + out}
+		gt.NoError(t, cli.Run(context.Background(), args))
+		gt.Equal(t, string(gt.R1(os.ReadFile(out)).NoError(t)), "found\n")
+
+		args = []string{"zenv", "-C", filepath.Join(dir, "missing"), "true"}
+		gt.Error(t, cli.Run(context.Background(), args))
+	})
 }
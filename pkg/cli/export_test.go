@@ -0,0 +1,8 @@
+package cli
+
+import "github.com/m-mizutani/zenv/v2/pkg/executor"
+
+func init() {
+	// Commands run by tests must not replace the test process
+	defaultExecMode = executor.ExecSpawn
+}
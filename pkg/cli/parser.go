@@ -83,6 +83,8 @@ type Option struct {
 type ParseResult struct {
 	Options map[string]OptionValue // Parsed options
 	Args    []string               // Remaining arguments (command to execute)
+	// Terminated is true when Args follow the "--" end of options marker
+	Terminated bool
 }
 
 // Parser defines the interface for command line parsing
@@ -196,6 +198,7 @@ func (p *DefaultParser) Parse(ctx context.Context, args []string) (*ParseResult,
 		if arg == "--" {
 			// Everything after -- should be treated as arguments
 			result.Args = append(result.Args, args[i+1:]...)
+			result.Terminated = true
 			break
 		}
 
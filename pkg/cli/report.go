@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+// reportHandler records warnings in a report, regardless of the log level,
+// and passes records to the next handler.
+type reportHandler struct {
+	next    slog.Handler
+	report  *model.Report
+	secrets *redact.Secrets
+	attrs   []slog.Attr
+}
+
+func newReportHandler(next slog.Handler, report *model.Report, secrets *redact.Secrets) slog.Handler {
+	return &reportHandler{next: next, report: report, secrets: secrets}
+}
+
+func (h *reportHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn || h.next.Enabled(ctx, level)
+}
+
+func (h *reportHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		msg := []string{r.Message}
+		add := func(a slog.Attr) bool {
+			msg = append(msg, fmt.Sprintf("%s=%v", a.Key, a.Value.Resolve()))
+			return true
+		}
+		for _, a := range h.attrs {
+			add(a)
+		}
+		r.Attrs(add)
+		h.report.AddWarning(h.secrets.Scrub(strings.Join(msg, " ")))
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *reportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &reportHandler{
+		next:    h.next.WithAttrs(attrs),
+		report:  h.report,
+		secrets: h.secrets,
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+func (h *reportHandler) WithGroup(name string) slog.Handler {
+	return &reportHandler{next: h.next.WithGroup(name), report: h.report, secrets: h.secrets, attrs: h.attrs}
+}
+
+// writeReport writes report to path as JSON
+func writeReport(path string, report *model.Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return goerr.Wrap(err, "failed to encode report")
+	}
+	if err := os.WriteFile(filepath.Clean(path), append(data, '\n'), 0o600); err != nil {
+		return goerr.Wrap(err, "failed to write report", goerr.V("path", path))
+	}
+	return nil
+}
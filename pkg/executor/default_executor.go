@@ -3,50 +3,157 @@ package executor
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"syscall"
 
 	"github.com/m-mizutani/ctxlog"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
 )
 
-func NewDefaultExecutor() ExecuteFunc {
+func NewDefaultExecutor(opts ...Option) ExecuteFunc {
+	cfg := newConfig(opts)
+
 	return func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
 		logger := ctxlog.From(ctx)
 		logger.Debug("executing command", "cmd", cmd, "args", args, "env_vars", len(envVars))
 
-		command := exec.CommandContext(ctx, cmd, args...)
+		// Cancellation of ctx is handled by runWithSignalRelay, which gives
+		// the child a grace period instead of killing it right away.
+		command := exec.Command(cmd, args...)
+
+		// Write as_file variables to a private directory; the files are
+		// removed when the command exits.
+		childVars, secretDir, err := materializeFiles(envVars)
+		if err != nil {
+			return model.NewExecutorError(err, 1)
+		}
+		if secretDir != nil {
+			defer func() {
+				if err := secretDir.Remove(); err != nil {
+					logger.Warn("failed to remove secret files", "error", err)
+				}
+			}()
+		}
 
 		// Set environment variables
-		env := os.Environ()
-		for _, envVar := range envVars {
+		var env []string
+		if cfg.inherit {
+			env = os.Environ()
+		}
+		for _, envVar := range childVars {
 			env = append(env, fmt.Sprintf("%s=%s", envVar.Name, envVar.Value))
 		}
+		if cfg.dir != "" {
+			command.Dir = cfg.dir
+			if abs, err := filepath.Abs(cfg.dir); err == nil {
+				env = append(env, "PWD="+abs)
+			}
+		}
 		command.Env = env
 
 		// Collect secret values for redaction
-		var secrets []string
+		var secrets []secretValue
 		for _, envVar := range envVars {
 			if envVar.Secret && envVar.Value != "" {
-				secrets = append(secrets, envVar.Value)
+				secrets = append(secrets, secretValue{
+					value: envVar.Value,
+					mask:  cfg.mask.Mask(envVar.Name, envVar.Value),
+				})
+			}
+		}
+
+		stdin := stdinFrom(ctx)
+		out := outputFrom(ctx)
+		capture := captureFrom(ctx)
+
+		// Replace zenv with the command when it has nothing to do while the
+		// command runs.
+		if cfg.execMode != ExecSpawn {
+			reason := spawnReasonFrom(ctx)
+			switch {
+			case reason != "":
+				// The caller needs zenv to outlive the command
+			case len(secrets) > 0:
+				reason = "output redaction"
+			case secretDir != nil:
+				reason = "secret file cleanup"
+			case stdin != nil:
+				reason = "stdin substitution"
+			case cfg.outputLog != nil:
+				reason = "output log"
+			case out != nil || capture != nil:
+				reason = "output capture"
+			}
+
+			if reason == "" {
+				logger.Debug("replacing zenv with command", "cmd", cmd)
+				err := execCommand(cmd, args, env, cfg.dir)
+				logger.Debug("failed to exec command", "cmd", cmd, "error", err)
+				return model.NewExecutorError(err, 1)
+			}
+			if cfg.execMode == ExecPreferred {
+				logger.Warn("running command as a child process", "reason", reason)
 			}
 		}
 
 		// Set up standard streams with optional redaction
 		command.Stdin = os.Stdin
+		if stdin != nil {
+			command.Stdin = stdin
+		}
+		stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+		if out != nil {
+			stdout, stderr = out.stdout, out.stderr
+		}
+		var outLog *outputLog
+		var outLogStdout, outLogStderr *outputLogStream
+		if cfg.outputLog != nil {
+			// The log is fed from behind the redactors, so that it gets
+			// exactly what reaches the terminal
+			outLog = newOutputLog(*cfg.outputLog)
+			outLogStdout, outLogStderr = outLog.stream("out"), outLog.stream("err")
+			stdout = io.MultiWriter(stdout, outLogStdout)
+			stderr = io.MultiWriter(stderr, outLogStderr)
+		}
 		var stdoutRedactor, stderrRedactor *redactWriter
 		if len(secrets) > 0 {
-			stdoutRedactor = newRedactWriter(os.Stdout, secrets)
-			stderrRedactor = newRedactWriter(os.Stderr, secrets)
+			secrets = expandSecretVariants(secrets, cfg.encodings)
+			stdoutRedactor = newRedactWriter(stdout, secrets, cfg.flushDelay)
+			stderrRedactor = newRedactWriter(stderr, secrets, cfg.flushDelay)
 			command.Stdout = stdoutRedactor
 			command.Stderr = stderrRedactor
 		} else {
-			command.Stdout = os.Stdout
-			command.Stderr = os.Stderr
+			command.Stdout = stdout
+			command.Stderr = stderr
+		}
+		if capture != nil {
+			command.Stdout = capture
 		}
 
-		err := command.Run()
+		// With redaction, a pseudo-terminal keeps TTY behaviour for the child
+		var session *ptySession
+		if cfg.pty && stdoutRedactor != nil {
+			if stdin == nil && out == nil && capture == nil && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+				session, err = newPTYSession(command, stdoutRedactor)
+				if err != nil {
+					logger.Warn("failed to allocate pseudo-terminal, using pipes", "error", err)
+				}
+			} else {
+				logger.Debug("PTY mode requires a terminal, using pipes")
+			}
+		}
+
+		// zenv stays alive until the child exits, so that secret files are
+		// removed and output is flushed; signals are relayed to the child.
+		if session != nil {
+			err = runWithSignalRelay(ctx, command, newSessionGroup(), cfg.killGrace, session.started)
+			session.Close()
+		} else {
+			err = runWithSignalRelay(ctx, command, newProcessGroup(command, ownProcessGroupFrom(ctx)), cfg.killGrace, nil)
+		}
 
 		// Flush any remaining buffered data from redact writers
 		if stdoutRedactor != nil {
@@ -55,10 +162,21 @@ func NewDefaultExecutor() ExecuteFunc {
 		if stderrRedactor != nil {
 			_ = stderrRedactor.Flush()
 		}
+		if outLog != nil {
+			outLogStdout.Flush()
+			outLogStderr.Flush()
+			if err := outLog.Err(); err != nil {
+				logger.Warn("failed to write output log", "error", err)
+			}
+		}
 		if err != nil {
 			// Extract exit code
 			if exitError, ok := err.(*exec.ExitError); ok {
 				if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+					if status.Signaled() {
+						logger.Debug("command terminated by signal", "cmd", cmd, "signal", status.Signal())
+						return model.NewSignaledExecutorError(err, status.Signal())
+					}
 					exitCode := status.ExitStatus()
 					logger.Debug("command exited with non-zero code", "cmd", cmd, "exit_code", exitCode)
 					return model.NewExecutorError(err, exitCode)
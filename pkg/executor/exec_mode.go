@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ExecMode selects whether the executor replaces the zenv process with the
+// command instead of running it as a child.
+type ExecMode int
+
+const (
+	// ExecSpawn always runs the command as a child process
+	ExecSpawn ExecMode = iota
+	// ExecAuto replaces zenv with the command by exec(2) when zenv has no
+	// work left during the run: no output to redact, no secret files to
+	// remove and no stdin to feed.
+	ExecAuto
+	// ExecPreferred is ExecAuto requested explicitly; falling back to a
+	// child process is logged as a warning.
+	ExecPreferred
+)
+
+// execCommand replaces the current process with cmd, run in dir if not
+// empty. It returns only on failure.
+func execCommand(cmd string, args []string, env []string, dir string) error {
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			return err
+		}
+	}
+	path, err := exec.LookPath(cmd)
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, append([]string{cmd}, args...), env) // #nosec G204 - running the user's command is the purpose of zenv
+}
+
+type spawnReasonKey struct{}
+
+// ContextWithSpawn returns a context that makes the executor run the command
+// as a child process, because the caller has work to do after it exits.
+func ContextWithSpawn(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, spawnReasonKey{}, reason)
+}
+
+func spawnReasonFrom(ctx context.Context) string {
+	reason, _ := ctx.Value(spawnReasonKey{}).(string)
+	return reason
+}
@@ -1,14 +1,20 @@
 package executor_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/m-mizutani/gt"
 	"github.com/m-mizutani/zenv/v2/pkg/executor"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
 )
 
 func TestDefaultExecutor(t *testing.T) {
@@ -119,6 +125,59 @@ func TestDefaultExecutor(t *testing.T) {
 		gt.NoError(t, err)
 	})
 
+	t.Run("Feed stdin from context", func(t *testing.T) {
+		execFunc := executor.NewDefaultExecutor()
+		ctx := executor.ContextWithStdin(context.Background(), strings.NewReader("from-context\n"))
+
+		err := execFunc(ctx, "sh", []string{"-c", `read -r v; [ "$v" = "from-context" ]`}, nil)
+		gt.NoError(t, err)
+	})
+
+	t.Run("Write output to context writers and capture stdout", func(t *testing.T) {
+		execFunc := executor.NewDefaultExecutor()
+		envVars := []*model.EnvVar{
+			{Name: "TOKEN", Value: "s3cr3t-value", Secret: true, Source: model.SourceInline},
+		}
+		script := `echo "out $TOKEN"; echo "err $TOKEN" >&2`
+
+		var stdout, stderr bytes.Buffer
+		ctx := executor.ContextWithOutput(context.Background(), &stdout, &stderr)
+		gt.NoError(t, execFunc(ctx, "sh", []string{"-c", script}, envVars))
+		gt.Equal(t, stdout.String(), "out *****\n")
+		gt.Equal(t, stderr.String(), "err *****\n")
+
+		// Captured stdout is not redacted, stderr still is
+		var captured bytes.Buffer
+		stderr.Reset()
+		ctx = executor.ContextWithCapture(ctx, &captured)
+		gt.NoError(t, execFunc(ctx, "sh", []string{"-c", script}, envVars))
+		gt.Equal(t, captured.String(), "out s3cr3t-value\n")
+		gt.Equal(t, stderr.String(), "err *****\n")
+	})
+
+	t.Run("Do not inherit zenv's environment when disabled", func(t *testing.T) {
+		t.Setenv("ZENV_TEST_INHERITED", "inherited")
+		envVars := []*model.EnvVar{
+			{Name: "GIVEN", Value: "given", Source: model.SourceInline},
+		}
+
+		execFunc := executor.NewDefaultExecutor()
+		gt.NoError(t, execFunc(context.Background(), "sh", []string{"-c", `[ "$ZENV_TEST_INHERITED" = "inherited" ]`}, envVars))
+
+		execFunc = executor.NewDefaultExecutor(executor.WithInheritEnv(false))
+		gt.NoError(t, execFunc(context.Background(), "sh", []string{"-c", `[ -z "$ZENV_TEST_INHERITED" ] && [ "$GIVEN" = "given" ]`}, envVars))
+	})
+
+	t.Run("Run in the given directory", func(t *testing.T) {
+		dir := gt.R1(filepath.EvalSymlinks(t.TempDir())).NoError(t)
+		envVars := []*model.EnvVar{
+			{Name: "WANT", Value: dir, Source: model.SourceInline},
+		}
+
+		execFunc := executor.NewDefaultExecutor(executor.WithDir(dir))
+		gt.NoError(t, execFunc(context.Background(), "sh", []string{"-c", `[ "$(pwd -P)" = "$WANT" ] && [ "$PWD" = "$WANT" ]`}, envVars))
+	})
+
 	t.Run("Redact secret values in stdout", func(t *testing.T) {
 		r, w, pipeErr := os.Pipe()
 		gt.NoError(t, pipeErr)
@@ -169,6 +228,53 @@ func TestDefaultExecutor(t *testing.T) {
 		gt.S(t, string(output)).Contains("*****")
 	})
 
+	t.Run("Redact encoded secret values unless disabled", func(t *testing.T) {
+		envVars := []*model.EnvVar{
+			{Name: "SECRET_TOKEN", Value: "my-secret-123", Source: model.SourceYAML, Secret: true},
+		}
+		script := "printf my-secret-123 | base64"
+
+		run := func(opts ...executor.Option) string {
+			r, w, pipeErr := os.Pipe()
+			gt.NoError(t, pipeErr)
+
+			oldStdout := os.Stdout
+			os.Stdout = w
+			defer func() { os.Stdout = oldStdout }()
+
+			err := executor.NewDefaultExecutor(opts...)(context.Background(), "sh", []string{"-c", script}, envVars)
+			gt.NoError(t, err)
+
+			gt.NoError(t, w.Close())
+			return string(gt.R1(io.ReadAll(r)).NoError(t))
+		}
+
+		gt.Equal(t, run(), "*****\n")
+		gt.Equal(t, run(executor.WithRedactEncodings()), "bXktc2VjcmV0LTEyMw==\n")
+	})
+
+	t.Run("Redact with mask policy", func(t *testing.T) {
+		r, w, pipeErr := os.Pipe()
+		gt.NoError(t, pipeErr)
+
+		oldStdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = oldStdout }()
+
+		execFunc := executor.NewDefaultExecutor(executor.WithMaskPolicy(redact.Policy{Kind: redact.PolicyName}))
+		envVars := []*model.EnvVar{
+			{Name: "SECRET_TOKEN", Value: "my-secret-123", Source: model.SourceYAML, Secret: true},
+		}
+
+		// bXktc2VjcmV0LTEyMw== is base64 of the secret
+		err := execFunc(context.Background(), "sh", []string{"-c", "echo my-secret-123 bXktc2VjcmV0LTEyMw=="}, envVars)
+		gt.NoError(t, err)
+
+		gt.NoError(t, w.Close())
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+		gt.Equal(t, output, "[REDACTED:SECRET_TOKEN] [REDACTED:SECRET_TOKEN]\n")
+	})
+
 	t.Run("No redaction when no secret env vars", func(t *testing.T) {
 		r, w, pipeErr := os.Pipe()
 		gt.NoError(t, pipeErr)
@@ -191,4 +297,71 @@ func TestDefaultExecutor(t *testing.T) {
 
 		gt.S(t, string(output)).Contains("visible")
 	})
+
+	t.Run("Materialize as_file variable and remove it after exit", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+		outDir := t.TempDir()
+
+		execFunc := executor.NewDefaultExecutor()
+		envVars := []*model.EnvVar{
+			{Name: "CRED_FILE", Value: "file-content", Source: model.SourceYAML, AsFile: true, FileMode: 0o400},
+		}
+
+		script := `cat "$CRED_FILE" > "$OUT/content"; echo -n "$CRED_FILE" > "$OUT/path"; stat -c %a "$CRED_FILE" > "$OUT/mode"`
+		err := execFunc(context.Background(), "sh", []string{"-c", script}, append(envVars,
+			&model.EnvVar{Name: "OUT", Value: outDir, Source: model.SourceInline}))
+		gt.NoError(t, err)
+
+		content := gt.R1(os.ReadFile(filepath.Join(outDir, "content"))).NoError(t)
+		gt.Equal(t, string(content), "file-content")
+		mode := gt.R1(os.ReadFile(filepath.Join(outDir, "mode"))).NoError(t)
+		gt.Equal(t, strings.TrimSpace(string(mode)), "400")
+
+		path := gt.R1(os.ReadFile(filepath.Join(outDir, "path"))).NoError(t)
+		gt.S(t, string(path)).HasPrefix(os.Getenv("XDG_RUNTIME_DIR"))
+		_, statErr := os.Stat(filepath.Dir(string(path)))
+		gt.True(t, os.IsNotExist(statErr))
+	})
+}
+
+// TestExecModeHelper runs the executor in a subprocess for TestExecMode, since
+// exec replaces the process.
+func TestExecModeHelper(t *testing.T) {
+	if os.Getenv("ZENV_TEST_EXEC_HELPER") == "" {
+		t.Skip("helper for TestExecMode")
+	}
+
+	var envVars []*model.EnvVar
+	if secret := os.Getenv("ZENV_TEST_EXEC_SECRET"); secret != "" {
+		envVars = append(envVars, &model.EnvVar{Name: "SECRET", Value: secret, Secret: true})
+	}
+
+	execFunc := executor.NewDefaultExecutor(executor.WithExecMode(executor.ExecAuto))
+	err := execFunc(context.Background(), "sh", []string{"-c", `echo "pid=$$ secret=$SECRET"`}, envVars)
+	gt.NoError(t, err)
+	os.Exit(0)
+}
+
+func TestExecMode(t *testing.T) {
+	runHelper := func(t *testing.T, env ...string) (int, string) {
+		t.Helper()
+		cmd := exec.Command(os.Args[0], "-test.run=^TestExecModeHelper$")
+		cmd.Env = append(append(os.Environ(), "ZENV_TEST_EXEC_HELPER=1"), env...)
+		var out strings.Builder
+		cmd.Stdout = &out
+		gt.NoError(t, cmd.Run())
+		return cmd.Process.Pid, out.String()
+	}
+
+	t.Run("Replace zenv with the command when no redaction is needed", func(t *testing.T) {
+		pid, out := runHelper(t)
+		gt.S(t, out).Contains(fmt.Sprintf("pid=%d ", pid))
+	})
+
+	t.Run("Run a child process when redaction is needed", func(t *testing.T) {
+		pid, out := runHelper(t, "ZENV_TEST_EXEC_SECRET=exec-secret-value")
+		gt.S(t, out).NotContains(fmt.Sprintf("pid=%d ", pid))
+		gt.S(t, out).Contains("secret=*****")
+		gt.S(t, out).NotContains("exec-secret-value")
+	})
 }
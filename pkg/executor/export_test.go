@@ -1,15 +1,44 @@
 package executor
 
-import "io"
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
 
 // RedactWriterForTest wraps redactWriter for testing.
 type RedactWriterForTest struct {
 	w *redactWriter
 }
 
-// NewRedactWriterForTest exposes newRedactWriter for testing.
+func fixedMask(secrets []string) []secretValue {
+	values := make([]secretValue, len(secrets))
+	for i, s := range secrets {
+		values[i] = secretValue{value: s, mask: redact.Mask}
+	}
+	return values
+}
+
+// NewRedactWriterForTest exposes newRedactWriter with the fixed mask for testing.
 func NewRedactWriterForTest(dest io.Writer, secrets []string) *RedactWriterForTest {
-	return &RedactWriterForTest{w: newRedactWriter(dest, secrets)}
+	return &RedactWriterForTest{w: newRedactWriter(dest, fixedMask(secrets), 0)}
+}
+
+// NewRedactWriterWithDelayForTest exposes newRedactWriter with a flush delay for testing.
+func NewRedactWriterWithDelayForTest(dest io.Writer, secrets []string, flushDelay time.Duration) *RedactWriterForTest {
+	return &RedactWriterForTest{w: newRedactWriter(dest, fixedMask(secrets), flushDelay)}
+}
+
+// NewMaskedRedactWriterForTest exposes newRedactWriter with a mask per secret
+// (secret value -> mask) for testing.
+func NewMaskedRedactWriterForTest(dest io.Writer, masks map[string]string) *RedactWriterForTest {
+	var values []secretValue
+	for s, mask := range masks {
+		values = append(values, secretValue{value: s, mask: mask})
+	}
+	return &RedactWriterForTest{w: newRedactWriter(dest, values, 0)}
 }
 
 func (t *RedactWriterForTest) Write(p []byte) (int, error) {
@@ -20,3 +49,17 @@ func (t *RedactWriterForTest) Write(p []byte) (int, error) {
 func (t *RedactWriterForTest) Flush() error {
 	return t.w.Flush()
 }
+
+// ExpandSecretVariants exposes expandSecretVariants for testing.
+func ExpandSecretVariants(secrets []string, encodings []Encoding) []string {
+	var values []string
+	for _, v := range expandSecretVariants(fixedMask(secrets), encodings) {
+		values = append(values, v.value)
+	}
+	return values
+}
+
+// OpenPTY exposes openPTY for testing.
+func OpenPTY() (*os.File, *os.File, error) {
+	return openPTY()
+}
@@ -0,0 +1,132 @@
+package executor
+
+import "sort"
+
+// matcher is an Aho-Corasick automaton over a set of byte patterns. It is fed
+// one byte at a time, so a match can span any number of Write calls.
+type matcher struct {
+	// root holds the complete transition table of the root state, which is
+	// where the automaton spends most of its time on ordinary output.
+	root [256]int
+	// next holds the goto edges of each state, sorted by byte
+	next [][]edge
+	// fail is the failure link: the longest proper suffix that is also a state
+	fail []int
+	// depth is the length of the prefix represented by the state
+	depth []int
+	// pattern is the index+1 of the pattern ending exactly at the state, or 0
+	pattern []int
+	// dict links to the nearest state on the failure chain that ends a pattern
+	dict []int
+	// patternLen is the length of each pattern
+	patternLen []int
+}
+
+func newMatcher(patterns [][]byte) *matcher {
+	m := &matcher{
+		next:    [][]edge{nil},
+		fail:    []int{0},
+		depth:   []int{0},
+		pattern: []int{0},
+		dict:    []int{0},
+	}
+
+	// Build the trie
+	for idx, p := range patterns {
+		m.patternLen = append(m.patternLen, len(p))
+		state := 0
+		for _, b := range p {
+			child := m.child(state, b)
+			if child == 0 {
+				child = len(m.next)
+				m.next = append(m.next, nil)
+				m.fail = append(m.fail, 0)
+				m.depth = append(m.depth, m.depth[state]+1)
+				m.pattern = append(m.pattern, 0)
+				m.dict = append(m.dict, 0)
+				m.addEdge(state, b, child)
+			}
+			state = child
+		}
+		if m.pattern[state] == 0 {
+			m.pattern[state] = idx + 1
+		}
+	}
+
+	// Compute failure and dictionary links in BFS order
+	var queue []int
+	for _, e := range m.next[0] {
+		m.root[e.b] = e.to
+		queue = append(queue, e.to)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, e := range m.next[state] {
+			child := e.to
+			m.fail[child] = m.step(m.fail[state], e.b)
+			if f := m.fail[child]; m.pattern[f] != 0 {
+				m.dict[child] = f
+			} else {
+				m.dict[child] = m.dict[f]
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+type edge struct {
+	b  byte
+	to int
+}
+
+// child returns the goto edge of state for b, or 0 if there is none
+func (m *matcher) child(state int, b byte) int {
+	edges := m.next[state]
+	if len(edges) <= 8 {
+		for _, e := range edges {
+			if e.b == b {
+				return e.to
+			}
+		}
+		return 0
+	}
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].b >= b })
+	if i < len(edges) && edges[i].b == b {
+		return edges[i].to
+	}
+	return 0
+}
+
+// step returns the state after consuming b
+func (m *matcher) step(state int, b byte) int {
+	for state != 0 {
+		if child := m.child(state, b); child != 0 {
+			return child
+		}
+		state = m.fail[state]
+	}
+	return m.root[b]
+}
+
+func (m *matcher) addEdge(state int, b byte, to int) {
+	edges := m.next[state]
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].b >= b })
+	edges = append(edges, edge{})
+	copy(edges[i+1:], edges[i:])
+	edges[i] = edge{b: b, to: to}
+	m.next[state] = edges
+}
+
+// matches calls fn with the index of every pattern ending at state, longest first
+func (m *matcher) matches(state int, fn func(pattern int)) {
+	if m.pattern[state] == 0 {
+		state = m.dict[state]
+	}
+	for state != 0 {
+		fn(m.pattern[state] - 1)
+		state = m.dict[state]
+	}
+}
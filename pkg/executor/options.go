@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"time"
+
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+// Option configures the executor created by NewDefaultExecutor
+type Option func(*config)
+
+type config struct {
+	encodings  []Encoding
+	flushDelay time.Duration
+	mask       redact.Policy
+	killGrace  time.Duration
+	execMode   ExecMode
+	pty        bool
+	inherit    bool
+	dir        string
+	outputLog  *OutputLog
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		encodings:  DefaultEncodings,
+		flushDelay: DefaultFlushDelay,
+		killGrace:  DefaultKillGrace,
+		inherit:    true,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithRedactEncodings sets the encoded variants of secret values that are
+// redacted in addition to the raw values. No arguments means raw values only.
+func WithRedactEncodings(encodings ...Encoding) Option {
+	return func(c *config) {
+		c.encodings = encodings
+	}
+}
+
+// WithFlushDelay sets how long redacted output that cannot be part of a secret
+// may be held back waiting for more data. Zero disables the time-based flush,
+// so held bytes are written only on newline, more output or exit.
+func WithFlushDelay(d time.Duration) Option {
+	return func(c *config) {
+		c.flushDelay = d
+	}
+}
+
+// WithMaskPolicy sets how secret values are replaced in command output
+func WithMaskPolicy(p redact.Policy) Option {
+	return func(c *config) {
+		c.mask = p
+	}
+}
+
+// WithKillGrace sets how long the child may take to exit after a terminating
+// signal before it is killed with SIGKILL. Zero never kills it.
+func WithKillGrace(d time.Duration) Option {
+	return func(c *config) {
+		c.killGrace = d
+	}
+}
+
+// WithExecMode sets whether the command may replace the zenv process. The
+// default is ExecSpawn.
+func WithExecMode(mode ExecMode) Option {
+	return func(c *config) {
+		c.execMode = mode
+	}
+}
+
+// WithPTY runs the command on a pseudo-terminal when its output is redacted
+// and zenv runs on a terminal, so that the command keeps TTY behaviour such as
+// colours and line editing. Stdout and stderr are merged in this mode.
+func WithPTY(enabled bool) Option {
+	return func(c *config) {
+		c.pty = enabled
+	}
+}
+
+// WithInheritEnv sets whether the command inherits zenv's own environment in
+// addition to the given variables. Disable it when the variables already
+// include the system environment that should reach the command.
+func WithInheritEnv(inherit bool) Option {
+	return func(c *config) {
+		c.inherit = inherit
+	}
+}
+
+// WithDir sets the working directory of the command. Empty means zenv's own.
+func WithDir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// WithOutputLog writes a copy of the command's stdout and stderr, after
+// redaction, to log.Writer while the output still goes to zenv's own streams.
+func WithOutputLog(log OutputLog) Option {
+	return func(c *config) {
+		c.outputLog = &log
+	}
+}
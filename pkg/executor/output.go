@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"context"
+	"io"
+)
+
+type outputKey struct{}
+
+type output struct {
+	stdout, stderr io.Writer
+}
+
+// ContextWithOutput returns a context that makes the executor write the
+// command's stdout and stderr, after redaction, to the given writers instead
+// of zenv's own streams.
+func ContextWithOutput(ctx context.Context, stdout, stderr io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, &output{stdout: stdout, stderr: stderr})
+}
+
+func outputFrom(ctx context.Context) *output {
+	out, _ := ctx.Value(outputKey{}).(*output)
+	return out
+}
+
+type captureKey struct{}
+
+// ContextWithCapture returns a context that makes the executor write the
+// command's stdout to w as is, bypassing redaction and the output log, for
+// output that zenv reads itself.
+func ContextWithCapture(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, captureKey{}, w)
+}
+
+func captureFrom(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(captureKey{}).(io.Writer)
+	return w
+}
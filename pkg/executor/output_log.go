@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// OutputLog configures the copy of the command's output written by
+// WithOutputLog. The copy is taken after redaction.
+type OutputLog struct {
+	Writer io.Writer
+	// JSON writes one JSON object per line with time, stream and text
+	JSON bool
+	// Timestamps prefixes each line with the time it was written
+	Timestamps bool
+	// Tags prefixes each line with [out] or [err]
+	Tags bool
+}
+
+// outputLogTimeFormat is the timestamp format of text output logs
+const outputLogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// outputLog writes the output of both streams to OutputLog.Writer. Write errors
+// do not interrupt the command; the first one is kept for the caller.
+type outputLog struct {
+	cfg OutputLog
+	now func() time.Time
+
+	mu  sync.Mutex
+	err error
+}
+
+func newOutputLog(cfg OutputLog) *outputLog {
+	return &outputLog{cfg: cfg, now: time.Now}
+}
+
+// lineMode reports whether output is written line by line with decoration
+func (l *outputLog) lineMode() bool {
+	return l.cfg.JSON || l.cfg.Timestamps || l.cfg.Tags
+}
+
+func (l *outputLog) write(p []byte) {
+	if l.err != nil {
+		return
+	}
+	if _, err := l.cfg.Writer.Write(p); err != nil {
+		l.err = err
+	}
+}
+
+func (l *outputLog) writeLine(stream string, at time.Time, line []byte) {
+	// Lines from a pseudo-terminal end with CRLF
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	var buf bytes.Buffer
+	if l.cfg.JSON {
+		data, err := json.Marshal(struct {
+			Time   time.Time `json:"time"`
+			Stream string    `json:"stream"`
+			Text   string    `json:"text"`
+		}{at, stream, string(line)})
+		if err != nil {
+			l.err = err
+			return
+		}
+		buf.Write(data)
+	} else {
+		if l.cfg.Timestamps {
+			buf.WriteString(at.Format(outputLogTimeFormat) + " ")
+		}
+		if l.cfg.Tags {
+			buf.WriteString("[" + stream + "] ")
+		}
+		buf.Write(line)
+	}
+	buf.WriteByte('\n')
+	l.write(buf.Bytes())
+}
+
+// Err returns the first error writing the log
+func (l *outputLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// stream returns a writer for the stream named name ("out" or "err")
+func (l *outputLog) stream(name string) *outputLogStream {
+	return &outputLogStream{log: l, name: name}
+}
+
+// outputLogStream splits one stream into lines for the output log
+type outputLogStream struct {
+	log  *outputLog
+	name string
+
+	line  []byte
+	start time.Time
+}
+
+// Write never fails, so that the command's output keeps flowing to the
+// terminal when the log cannot be written.
+func (s *outputLogStream) Write(p []byte) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if !s.log.lineMode() {
+		s.log.write(p)
+		return len(p), nil
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if len(s.line) == 0 {
+			s.start = s.log.now()
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			s.line = append(s.line, p...)
+			break
+		}
+		s.line = append(s.line, p[:i]...)
+		s.log.writeLine(s.name, s.start, s.line)
+		s.line = s.line[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush writes an unterminated last line
+func (s *outputLogStream) Flush() {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	if len(s.line) > 0 {
+		s.log.writeLine(s.name, s.start, s.line)
+		s.line = s.line[:0]
+	}
+}
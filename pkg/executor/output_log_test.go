@@ -0,0 +1,78 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestOutputLog(t *testing.T) {
+	envVars := []*model.EnvVar{
+		{Name: "TOKEN", Value: "my-secret-123", Source: model.SourceInline, Secret: true},
+	}
+	script := `echo "out $TOKEN"; echo "err $TOKEN" >&2; printf last`
+
+	run := func(t *testing.T, log executor.OutputLog, envVars []*model.EnvVar) string {
+		t.Helper()
+		var buf bytes.Buffer
+		log.Writer = &buf
+		execFunc := executor.NewDefaultExecutor(executor.WithOutputLog(log))
+		gt.NoError(t, execFunc(context.Background(), "sh", []string{"-c", script}, envVars))
+		return buf.String()
+	}
+
+	t.Run("Plain copy is redacted", func(t *testing.T) {
+		output := run(t, executor.OutputLog{}, envVars)
+		gt.S(t, output).NotContains("my-secret-123")
+		gt.S(t, output).Contains("out *****\n")
+		gt.S(t, output).Contains("err *****\n")
+	})
+
+	t.Run("Plain copy without secrets", func(t *testing.T) {
+		output := run(t, executor.OutputLog{}, []*model.EnvVar{{Name: "TOKEN", Value: "public"}})
+		gt.S(t, output).Contains("out public\n")
+	})
+
+	t.Run("Timestamps and tags", func(t *testing.T) {
+		output := run(t, executor.OutputLog{Timestamps: true, Tags: true}, envVars)
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		gt.A(t, lines).Length(3)
+
+		re := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}\S* \[(out|err)\] (.*)$`)
+		got := map[string]string{}
+		for _, line := range lines {
+			m := re.FindStringSubmatch(line)
+			gt.V(t, m).NotNil()
+			got[m[2]] = m[1]
+		}
+		gt.Equal(t, got, map[string]string{"out *****": "out", "err *****": "err", "last": "out"})
+	})
+
+	t.Run("JSON lines", func(t *testing.T) {
+		output := run(t, executor.OutputLog{JSON: true}, envVars)
+		gt.S(t, output).NotContains("my-secret-123")
+
+		var streams []string
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			var entry struct {
+				Time   string `json:"time"`
+				Stream string `json:"stream"`
+				Text   string `json:"text"`
+			}
+			gt.NoError(t, json.Unmarshal([]byte(line), &entry))
+			gt.S(t, entry.Time).NotEqual("")
+			streams = append(streams, entry.Stream+":"+entry.Text)
+		}
+		gt.A(t, streams).Length(3)
+		gt.A(t, streams).Has("out:out *****")
+		gt.A(t, streams).Has("err:err *****")
+		gt.A(t, streams).Has("out:last")
+	})
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"golang.org/x/term"
+)
+
+// ptySession runs a command on a pseudo-terminal, so that the command sees a
+// TTY while its output still goes through the redactor. zenv's terminal is
+// put in raw mode and window size changes are passed to the pseudo-terminal.
+type ptySession struct {
+	master, slave *os.File
+	out           io.Writer
+
+	restore   func()
+	stopInput func()
+	winch     chan os.Signal
+	copyDone  chan struct{}
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// newPTYSession connects command to a new pseudo-terminal whose output is
+// written to out. The command becomes the leader of a new session.
+func newPTYSession(command *exec.Cmd, out io.Writer) (*ptySession, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	_ = copyWinsize(os.Stdin, master)
+
+	command.Stdin = slave
+	command.Stdout = slave
+	command.Stderr = slave
+	setControllingTerminal(command)
+
+	return &ptySession{
+		master:    master,
+		slave:     slave,
+		out:       out,
+		restore:   func() {},
+		stopInput: func() {},
+		copyDone:  make(chan struct{}),
+	}, nil
+}
+
+// started begins relaying between zenv's terminal and the pseudo-terminal.
+// It must be called once the command has started.
+func (s *ptySession) started() {
+	// Only the command holds the slave now, so reading the master ends
+	// when the command and its children have exited.
+	_ = s.slave.Close()
+
+	if state, err := term.MakeRaw(int(os.Stdin.Fd())); err == nil {
+		s.restore = func() { _ = term.Restore(int(os.Stdin.Fd()), state) }
+	}
+
+	s.winch = make(chan os.Signal, 1)
+	notifyResize(s.winch)
+	go func() {
+		for range s.winch {
+			_ = copyWinsize(os.Stdin, s.master)
+		}
+	}()
+
+	// The input is copied only while the session is open, so that input
+	// typed afterwards reaches whatever runs next
+	if stop, err := copyInput(s.master, os.Stdin); err == nil {
+		s.stopInput = stop
+	}
+	go func() {
+		defer close(s.copyDone)
+		_, _ = io.Copy(s.out, s.master)
+	}()
+}
+
+// Close waits for the remaining output of the command and restores zenv's terminal
+func (s *ptySession) Close() {
+	s.stopInput()
+	if s.winch != nil {
+		<-s.copyDone
+		signal.Stop(s.winch)
+		close(s.winch)
+	} else {
+		_ = s.slave.Close()
+	}
+	s.restore()
+	_ = s.master.Close()
+}
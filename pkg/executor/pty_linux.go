@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/m-mizutani/goerr/v2"
+	"golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal and returns its master and slave ends
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, goerr.Wrap(err, "failed to open /dev/ptmx")
+	}
+
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close()
+		return nil, nil, goerr.Wrap(err, "failed to unlock pseudo-terminal")
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, goerr.Wrap(err, "failed to get pseudo-terminal number")
+	}
+
+	slavePath := fmt.Sprintf("/dev/pts/%d", n)
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, goerr.Wrap(err, "failed to open pseudo-terminal slave", goerr.V("path", slavePath))
+	}
+
+	return master, slave, nil
+}
+
+// copyWinsize sets the window size of the terminal to to that of from
+func copyWinsize(from, to *os.File) error {
+	ws, err := unix.IoctlGetWinsize(int(from.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return goerr.Wrap(err, "failed to get window size")
+	}
+	if err := unix.IoctlSetWinsize(int(to.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		return goerr.Wrap(err, "failed to set window size")
+	}
+	return nil
+}
+
+// setControllingTerminal makes command the leader of a new session whose
+// controlling terminal is its stdin
+func setControllingTerminal(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Setsid = true
+	command.SysProcAttr.Setctty = true
+	command.SysProcAttr.Ctty = 0 // the slave as the command's stdin
+}
+
+// notifyResize relays window size changes of zenv's terminal to ch
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
+
+// copyInput copies src to dst in the background until the returned function
+// is called. Unlike io.Copy, stopping does not wait for a pending read of src,
+// so no input is taken from the next reader of src.
+func copyInput(dst io.Writer, src *os.File) (func(), error) {
+	stopR, stopW, err := os.Pipe()
+	if err != nil {
+		return nil, goerr.Wrap(err, "failed to create pipe")
+	}
+
+	fd := int(src.Fd())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		fds := []unix.PollFd{
+			{Fd: int32(fd), Events: unix.POLLIN},         // #nosec G115 - file descriptors fit in int32
+			{Fd: int32(stopR.Fd()), Events: unix.POLLIN}, // #nosec G115
+		}
+		for {
+			if _, err := unix.Poll(fds, -1); err != nil {
+				if err == unix.EINTR {
+					continue
+				}
+				return
+			}
+			if fds[1].Revents != 0 {
+				return
+			}
+			if fds[0].Revents == 0 {
+				continue
+			}
+
+			n, err := unix.Read(fd, buf)
+			if err == unix.EINTR || err == unix.EAGAIN {
+				continue
+			}
+			if n <= 0 || err != nil {
+				return
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		_ = stopW.Close()
+		<-done
+		_ = stopR.Close()
+	}, nil
+}
@@ -0,0 +1,136 @@
+package executor_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"golang.org/x/sys/unix"
+)
+
+func TestPTYMode(t *testing.T) {
+	// Pretend zenv runs on a terminal by attaching stdin and stdout to a
+	// pseudo-terminal owned by the test.
+	master, slave := gt.R2(executor.OpenPTY()).NoError(t)
+	defer master.Close()
+	defer slave.Close()
+	gt.NoError(t, unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: 40, Col: 100}))
+
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = slave, slave
+	defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+
+	var output syncBuffer
+	go func() { _, _ = io.Copy(&output, master) }()
+
+	envVars := []*model.EnvVar{
+		{Name: "SECRET_TOKEN", Value: "my-secret-123", Source: model.SourceYAML, Secret: true},
+	}
+	execFunc := executor.NewDefaultExecutor(executor.WithPTY(true))
+	err := execFunc(context.Background(), "sh", []string{"-c", `[ -t 0 ] && [ -t 1 ] && echo "tty=yes size=$(stty size) token=$SECRET_TOKEN"`}, envVars)
+	gt.NoError(t, err)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && !strings.Contains(output.String(), "token=") {
+		time.Sleep(5 * time.Millisecond)
+	}
+	gt.S(t, output.String()).Contains("tty=yes size=40 100 token=*****")
+	gt.S(t, output.String()).NotContains("my-secret-123")
+}
+
+// TestProcessGroupHelper runs a command for TestProcessGroup and prints the
+// process groups of zenv and the command.
+func TestProcessGroupHelper(t *testing.T) {
+	if os.Getenv("ZENV_TEST_PGRP_HELPER") == "" {
+		t.Skip("helper for TestProcessGroup")
+	}
+
+	ctx := context.Background()
+	if os.Getenv("ZENV_TEST_PGRP_OWN") != "" {
+		ctx = executor.ContextWithProcessGroup(ctx)
+	}
+	execFunc := executor.NewDefaultExecutor(executor.WithExecMode(executor.ExecSpawn))
+	err := execFunc(ctx, "sh", []string{"-c", `read -r _ _ _ _ pgrp _ < /proc/$$/stat; echo "zenv=$ZENV_PGRP child=$pgrp"`},
+		[]*model.EnvVar{{Name: "ZENV_PGRP", Value: strconv.Itoa(syscall.Getpgrp())}})
+	gt.NoError(t, err)
+	os.Exit(0)
+}
+
+func TestProcessGroup(t *testing.T) {
+	runHelper := func(t *testing.T, ctty bool, env ...string) string {
+		t.Helper()
+		master, slave := gt.R2(executor.OpenPTY()).NoError(t)
+		defer master.Close()
+		defer slave.Close()
+
+		// stdin is not a terminal in both cases
+		cmd := exec.Command(os.Args[0], "-test.run=^TestProcessGroupHelper$")
+		cmd.Env = append(append(os.Environ(), "ZENV_TEST_PGRP_HELPER=1"), env...)
+		cmd.ExtraFiles = []*os.File{slave}
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: ctty, Ctty: 3}
+		var out strings.Builder
+		cmd.Stdout = &out
+		gt.NoError(t, cmd.Run())
+		return out.String()
+	}
+
+	t.Run("Stay in the foreground group of the controlling terminal", func(t *testing.T) {
+		var zenv, child int
+		gt.R1(fmt.Sscanf(runHelper(t, true), "zenv=%d child=%d", &zenv, &child)).NoError(t)
+		gt.Equal(t, child, zenv)
+	})
+
+	t.Run("Use an own group when requested", func(t *testing.T) {
+		var zenv, child int
+		gt.R1(fmt.Sscanf(runHelper(t, true, "ZENV_TEST_PGRP_OWN=1"), "zenv=%d child=%d", &zenv, &child)).NoError(t)
+		gt.NotEqual(t, child, zenv)
+	})
+
+	t.Run("Use an own group without a controlling terminal", func(t *testing.T) {
+		var zenv, child int
+		gt.R1(fmt.Sscanf(runHelper(t, false), "zenv=%d child=%d", &zenv, &child)).NoError(t)
+		gt.NotEqual(t, child, zenv)
+	})
+}
+
+func TestPTYModeReleasesInput(t *testing.T) {
+	master, slave := gt.R2(executor.OpenPTY()).NoError(t)
+	defer master.Close()
+	defer slave.Close()
+
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = slave, slave
+	defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+	go func() { _, _ = io.Copy(io.Discard, master) }()
+
+	envVars := []*model.EnvVar{
+		{Name: "SECRET_TOKEN", Value: "my-secret-123", Source: model.SourceYAML, Secret: true},
+	}
+	execFunc := executor.NewDefaultExecutor(executor.WithPTY(true))
+	gt.NoError(t, execFunc(context.Background(), "true", nil, envVars))
+
+	// Input typed after the command exited goes to the next reader
+	gt.R1(master.Write([]byte("next\n"))).NoError(t)
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := slave.Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case got := <-read:
+		gt.Equal(t, got, "next\n")
+	case <-time.After(2 * time.Second):
+		t.Fatal("input was taken by the finished session")
+	}
+}
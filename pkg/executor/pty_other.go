@@ -0,0 +1,27 @@
+//go:build !linux
+
+package executor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, goerr.New("PTY mode is supported on Linux only")
+}
+
+func copyWinsize(from, to *os.File) error {
+	return nil
+}
+
+func setControllingTerminal(command *exec.Cmd) {}
+
+func notifyResize(ch chan<- os.Signal) {}
+
+func copyInput(dst io.Writer, src *os.File) (func(), error) {
+	return nil, goerr.New("PTY mode is supported on Linux only")
+}
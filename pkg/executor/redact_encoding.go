@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Encoding is a representation of a secret value that programs commonly print
+// instead of the raw bytes. Each enabled encoding adds variants of every
+// secret to the redaction patterns.
+type Encoding string
+
+const (
+	// EncodingBase64 matches standard and URL-safe base64, including secrets
+	// embedded in a larger encoded payload such as a Basic auth header.
+	EncodingBase64 Encoding = "base64"
+	// EncodingURL matches query and path escaped forms.
+	EncodingURL Encoding = "url"
+	// EncodingJSON matches the contents of a JSON string literal.
+	EncodingJSON Encoding = "json"
+	// EncodingHex matches lower and upper case hex encoding.
+	EncodingHex Encoding = "hex"
+)
+
+// DefaultEncodings is the set of variants redacted when none is configured
+var DefaultEncodings = []Encoding{EncodingBase64, EncodingURL, EncodingJSON, EncodingHex}
+
+// minVariantLen is the shortest encoded variant used as a pattern. Shorter
+// fragments would match unrelated output too often.
+const minVariantLen = 4
+
+// ParseEncodings parses encoding names given as separate or comma separated
+// values. "none" disables all variants so that only raw values are redacted.
+func ParseEncodings(values []string) ([]Encoding, error) {
+	encodings := []Encoding{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			switch Encoding(name) {
+			case EncodingBase64, EncodingURL, EncodingJSON, EncodingHex:
+				encodings = append(encodings, Encoding(name))
+			case "none", "":
+				continue
+			default:
+				return nil, goerr.New("unknown redact encoding", goerr.V("encoding", name))
+			}
+		}
+	}
+	return encodings, nil
+}
+
+// expandSecretVariants returns the raw secrets followed by their encoded
+// variants for the given encodings, without duplicates. A variant is replaced
+// with the mask of the secret it was derived from.
+func expandSecretVariants(secrets []secretValue, encodings []Encoding) []secretValue {
+	seen := make(map[string]bool)
+	var result []secretValue
+	add := func(s, mask string) {
+		if s == "" || seen[s] {
+			return
+		}
+		seen[s] = true
+		result = append(result, secretValue{value: s, mask: mask})
+	}
+
+	for _, secret := range secrets {
+		add(secret.value, secret.mask)
+	}
+
+	for _, secret := range secrets {
+		if secret.value == "" {
+			continue
+		}
+		for _, encoding := range encodings {
+			for _, variant := range encodeSecret(secret.value, encoding) {
+				if len(variant) >= minVariantLen {
+					add(variant, secret.mask)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func encodeSecret(secret string, encoding Encoding) []string {
+	switch encoding {
+	case EncodingBase64:
+		return base64Variants(secret)
+	case EncodingURL:
+		return []string{url.QueryEscape(secret), url.PathEscape(secret)}
+	case EncodingJSON:
+		return jsonVariants(secret)
+	case EncodingHex:
+		encoded := hex.EncodeToString([]byte(secret))
+		return []string{encoded, strings.ToUpper(encoded)}
+	}
+	return nil
+}
+
+// base64Variants returns the padded encodings of secret and the characters
+// that are fully determined by the secret when it appears at any byte offset
+// of a larger encoded payload (e.g. "user:secret" in a Basic auth header).
+func base64Variants(secret string) []string {
+	var variants []string
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		variants = append(variants, enc.EncodeToString([]byte(secret)))
+
+		raw := enc.WithPadding(base64.NoPadding)
+		n := len(secret)
+		for offset := 0; offset < 3; offset++ {
+			data := append(make([]byte, offset), secret...)
+			encoded := raw.EncodeToString(data)
+			// Skip characters mixing bits of the unknown leading bytes, and
+			// trailing characters that depend on the bytes following the secret.
+			start := (8*offset + 5) / 6
+			end := 8 * (offset + n) / 6
+			if start < end {
+				variants = append(variants, encoded[start:end])
+			}
+		}
+	}
+	return variants
+}
+
+func jsonVariants(secret string) []string {
+	var variants []string
+
+	// encoding/json escapes <, > and & by default; other encoders usually don't
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(secret); err != nil {
+			continue
+		}
+		quoted := strings.TrimSpace(buf.String())
+		variants = append(variants, quoted[1:len(quoted)-1])
+	}
+
+	return variants
+}
@@ -0,0 +1,84 @@
+package executor_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+)
+
+func TestParseEncodings(t *testing.T) {
+	t.Run("comma separated and repeated values", func(t *testing.T) {
+		encodings := gt.R1(executor.ParseEncodings([]string{"base64,url", "HEX"})).NoError(t)
+		gt.A(t, encodings).Equal([]executor.Encoding{executor.EncodingBase64, executor.EncodingURL, executor.EncodingHex})
+	})
+
+	t.Run("none disables variants", func(t *testing.T) {
+		encodings := gt.R1(executor.ParseEncodings([]string{"none"})).NoError(t)
+		gt.A(t, encodings).Length(0)
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := executor.ParseEncodings([]string{"rot13"})
+		gt.Error(t, err)
+	})
+}
+
+func TestRedactEncodedSecrets(t *testing.T) {
+	const secret = "p@ss w0rd/&<tok>"
+
+	redact := func(t *testing.T, encodings []executor.Encoding, input string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		w := executor.NewRedactWriterForTest(&buf, executor.ExpandSecretVariants([]string{secret}, encodings))
+		writeAndFlush(t, w, input)
+		return buf.String()
+	}
+
+	t.Run("base64 encoded secret", func(t *testing.T) {
+		encoded := base64.StdEncoding.EncodeToString([]byte(secret))
+		out := redact(t, executor.DefaultEncodings, "token="+encoded+"\n")
+		gt.Equal(t, out, "token=*****\n")
+	})
+
+	t.Run("secret inside base64 Basic auth header", func(t *testing.T) {
+		for _, user := range []string{"a", "ab", "abc"} {
+			encoded := base64.StdEncoding.EncodeToString([]byte(user + ":" + secret))
+			out := redact(t, executor.DefaultEncodings, "Authorization: Basic "+encoded+"\n")
+			gt.S(t, out).NotContains(encoded)
+			gt.S(t, out).Contains("*****")
+		}
+	})
+
+	t.Run("URL encoded secret", func(t *testing.T) {
+		out := redact(t, executor.DefaultEncodings, "postgres://user:"+url.QueryEscape(secret)+"@db/app")
+		gt.Equal(t, out, "postgres://user:*****@db/app")
+	})
+
+	t.Run("JSON escaped secret", func(t *testing.T) {
+		out := redact(t, executor.DefaultEncodings, `{"password":"p@ss w0rd/&<tok>"}`)
+		gt.Equal(t, out, `{"password":"*****"}`)
+	})
+
+	t.Run("hex encoded secret", func(t *testing.T) {
+		out := redact(t, executor.DefaultEncodings, "key="+hex.EncodeToString([]byte(secret)))
+		gt.Equal(t, out, "key=*****")
+	})
+
+	t.Run("variants are not redacted when disabled", func(t *testing.T) {
+		encoded := hex.EncodeToString([]byte(secret))
+		out := redact(t, nil, "raw="+secret+" hex="+encoded)
+		gt.Equal(t, out, "raw=***** hex="+encoded)
+	})
+
+	t.Run("only selected variants are redacted", func(t *testing.T) {
+		hexEncoded := hex.EncodeToString([]byte(secret))
+		b64Encoded := base64.StdEncoding.EncodeToString([]byte(secret))
+		out := redact(t, []executor.Encoding{executor.EncodingHex}, hexEncoded+" "+b64Encoded)
+		gt.Equal(t, out, "***** "+b64Encoded)
+	})
+}
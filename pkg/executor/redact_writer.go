@@ -3,64 +3,186 @@ package executor
 import (
 	"bytes"
 	"io"
-	"sort"
+	"sync"
+	"time"
 )
 
-const redactMask = "*****"
+// secretValue is a redaction pattern and the text that replaces it
+type secretValue struct {
+	value string
+	mask  string
+}
 
-var redactMaskBytes = []byte(redactMask)
+// DefaultFlushDelay is how long output held back by redactWriter may wait for
+// more data before the part that cannot be a secret is written anyway.
+const DefaultFlushDelay = 50 * time.Millisecond
 
+// redactWriter replaces secret values in the stream with their masks. Matching is
+// done by an Aho-Corasick automaton that keeps its state across Write calls,
+// so each byte is examined once regardless of the number of secrets.
+//
+// To catch secrets split across writes, the tail of the output is held back
+// until more data arrives. When a newline is written, or the held bytes have
+// waited for flushDelay, everything that cannot be the start of a secret is
+// written out so that prompts and progress bars are not delayed.
 type redactWriter struct {
 	dest         io.Writer
-	secrets      [][]byte
+	m            *matcher
+	masks        [][]byte
 	maxSecretLen int
-	buf          []byte
+	flushDelay   time.Duration
+
+	mu    sync.Mutex
+	timer *time.Timer
+
+	// buf holds the bytes not yet written to dest
+	buf []byte
+	// scanned is the number of bytes of buf consumed by the automaton
+	scanned int
+	// state is the automaton state after consuming buf[:scanned]
+	state int
+	// pending is the best match found so far that a longer or earlier match
+	// may still replace
+	pending redactMatch
+	// redactions are the committed matches in buf, in order
+	redactions []redactMatch
 }
 
-func newRedactWriter(dest io.Writer, secrets []string) *redactWriter {
-	// Sort by length descending for longest-match-first semantics.
-	sorted := make([]string, len(secrets))
-	copy(sorted, secrets)
-	sort.Slice(sorted, func(i, j int) bool {
-		return len(sorted[i]) > len(sorted[j])
-	})
+type redactMatch struct {
+	start, end int
+	pattern    int
+}
 
-	secretBytes := make([][]byte, 0, len(sorted))
+var noMatch = redactMatch{start: -1, end: -1}
+
+func newRedactWriter(dest io.Writer, secrets []secretValue, flushDelay time.Duration) *redactWriter {
+	patterns := make([][]byte, 0, len(secrets))
+	masks := make([][]byte, 0, len(secrets))
 	maxLen := 0
-	for _, s := range sorted {
-		if len(s) == 0 {
+	for _, s := range secrets {
+		if len(s.value) == 0 {
 			continue
 		}
-		secretBytes = append(secretBytes, []byte(s))
-		if len(s) > maxLen {
-			maxLen = len(s)
+		patterns = append(patterns, []byte(s.value))
+		masks = append(masks, []byte(s.mask))
+		if len(s.value) > maxLen {
+			maxLen = len(s.value)
 		}
 	}
 
 	return &redactWriter{
 		dest:         dest,
-		secrets:      secretBytes,
+		m:            newMatcher(patterns),
+		masks:        masks,
 		maxSecretLen: maxLen,
+		flushDelay:   flushDelay,
+		pending:      noMatch,
 	}
 }
 
-// findEarliestMatch finds the earliest (leftmost) occurrence of any secret in
-// data. When multiple secrets match at the same position, the longest wins.
-// Returns (-1, 0) if no match is found.
-func (w *redactWriter) findEarliestMatch(data []byte) (pos int, length int) {
-	bestPos := -1
-	bestLen := 0
-	for _, secret := range w.secrets {
-		idx := bytes.Index(data, secret)
-		if idx == -1 {
-			continue
+// scan feeds the unscanned part of buf to the automaton. Matches are resolved
+// leftmost first; among matches at the same position the longest wins. A match
+// is committed once no longer or earlier match can still complete, and scanning
+// resumes right after it. With final set, the stream is known to have ended and
+// every remaining candidate is committed.
+func (w *redactWriter) scan(final bool) {
+	for {
+		m, buf := w.m, w.buf
+		for w.scanned < len(buf) {
+			// Fast path: skip bytes that cannot start a secret while the
+			// automaton is at the root and nothing is pending.
+			if w.state == 0 && w.pending.start < 0 {
+				i := w.scanned
+				for i < len(buf) && m.root[buf[i]] == 0 {
+					i++
+				}
+				w.scanned = i
+				if i == len(buf) {
+					break
+				}
+			}
+
+			w.state = m.step(w.state, buf[w.scanned])
+			w.scanned++
+
+			if m.pattern[w.state] != 0 || m.dict[w.state] != 0 {
+				m.matches(w.state, func(pattern int) {
+					start := w.scanned - m.patternLen[pattern]
+					if w.pending.start < 0 || start < w.pending.start ||
+						(start == w.pending.start && w.scanned > w.pending.end) {
+						w.pending = redactMatch{start: start, end: w.scanned, pattern: pattern}
+					}
+				})
+			}
+
+			// Any future match starts at or after the longest pattern prefix
+			// currently held by the automaton.
+			if w.pending.start >= 0 && w.pending.start < w.scanned-m.depth[w.state] {
+				w.commit()
+			}
 		}
-		if bestPos == -1 || idx < bestPos || (idx == bestPos && len(secret) > bestLen) {
-			bestPos = idx
-			bestLen = len(secret)
+
+		if !final || w.pending.start < 0 {
+			return
+		}
+		w.commit()
+	}
+}
+
+func (w *redactWriter) commit() {
+	w.redactions = append(w.redactions, w.pending)
+	w.scanned = w.pending.end
+	w.state = 0
+	w.pending = noMatch
+}
+
+// undecided returns the offset in buf from which the output may still be part
+// of a secret.
+func (w *redactWriter) undecided() int {
+	pos := w.scanned - w.m.depth[w.state]
+	if w.pending.start >= 0 && w.pending.start < pos {
+		pos = w.pending.start
+	}
+	return pos
+}
+
+// emit writes buf up to bound (and any redaction starting before it) to dest,
+// replacing committed matches with the mask.
+func (w *redactWriter) emit(bound int) error {
+	var output []byte
+	pos := 0
+	for len(w.redactions) > 0 && w.redactions[0].start < bound {
+		r := w.redactions[0]
+		output = append(output, w.buf[pos:r.start]...)
+		output = append(output, w.masks[r.pattern]...)
+		pos = r.end
+		w.redactions = w.redactions[1:]
+	}
+	if pos < bound {
+		output = append(output, w.buf[pos:bound]...)
+		pos = bound
+	}
+	if pos == 0 {
+		return nil
+	}
+
+	w.buf = append(w.buf[:0], w.buf[pos:]...)
+	w.scanned -= pos
+	if w.pending.start >= 0 {
+		w.pending.start -= pos
+		w.pending.end -= pos
+	}
+	for i := range w.redactions {
+		w.redactions[i].start -= pos
+		w.redactions[i].end -= pos
+	}
+
+	if len(output) > 0 {
+		if _, err := w.dest.Write(output); err != nil {
+			return err
 		}
 	}
-	return bestPos, bestLen
+	return nil
 }
 
 func (w *redactWriter) Write(p []byte) (int, error) {
@@ -72,60 +194,65 @@ func (w *redactWriter) Write(p []byte) (int, error) {
 		return len(p), nil
 	}
 
-	w.buf = append(w.buf, p...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	var output []byte
-	for len(w.buf) >= w.maxSecretLen {
-		pos, length := w.findEarliestMatch(w.buf)
-
-		if pos == -1 {
-			// No match anywhere. Flush everything except the tail that
-			// could be the beginning of a secret split across writes.
-			safeEnd := len(w.buf) - (w.maxSecretLen - 1)
-			output = append(output, w.buf[:safeEnd]...)
-			w.buf = w.buf[safeEnd:]
-			break
-		}
+	w.buf = append(w.buf, p...)
+	w.scan(false)
 
-		// Emit everything before the match, then the mask.
-		output = append(output, w.buf[:pos]...)
-		output = append(output, redactMaskBytes...)
-		w.buf = w.buf[pos+length:]
+	// Hold back the tail that could be the beginning of a secret split
+	// across writes, and anything the automaton has not decided yet. A
+	// newline ends the hold-back of bytes that cannot start a secret.
+	bound := w.undecided()
+	if !bytes.Contains(p, []byte{'\n'}) {
+		bound = min(len(w.buf)-(w.maxSecretLen-1), bound)
 	}
-
-	if len(output) > 0 {
-		if _, err := w.dest.Write(output); err != nil {
+	if bound > 0 {
+		if err := w.emit(bound); err != nil {
 			return 0, err
 		}
 	}
 
+	if w.flushDelay > 0 && w.undecided() > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(w.flushDelay, w.flushIdle)
+	}
+
 	return len(p), nil
 }
 
+// flushIdle writes the held-back bytes that cannot be the start of a secret
+// after they have waited for flushDelay.
+func (w *redactWriter) flushIdle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timer = nil
+	if bound := w.undecided(); bound > 0 {
+		_ = w.emit(bound)
+	}
+}
+
 // Flush writes any remaining buffered data to the destination.
 // Must be called after the child process finishes to ensure all output is emitted.
 func (w *redactWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
 	if len(w.buf) == 0 {
 		return nil
 	}
 
-	// Process remaining buffer, replacing any complete matches.
-	var output []byte
-	for {
-		pos, length := w.findEarliestMatch(w.buf)
-		if pos == -1 {
-			output = append(output, w.buf...)
-			break
-		}
-		output = append(output, w.buf[:pos]...)
-		output = append(output, redactMaskBytes...)
-		w.buf = w.buf[pos+length:]
-	}
+	w.scan(true)
+	err := w.emit(len(w.buf))
 
 	w.buf = nil
-	if len(output) > 0 {
-		_, err := w.dest.Write(output)
-		return err
-	}
-	return nil
+	w.scanned = 0
+	w.state = 0
+	w.pending = noMatch
+	w.redactions = nil
+	return err
 }
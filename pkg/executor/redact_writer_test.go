@@ -2,7 +2,14 @@ package executor_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/m-mizutani/gt"
 	"github.com/m-mizutani/zenv/v2/pkg/executor"
@@ -36,6 +43,17 @@ func TestRedactWriter(t *testing.T) {
 		gt.Equal(t, buf.String(), "a=***** b=*****")
 	})
 
+	t.Run("replace each secret with its own mask", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := executor.NewMaskedRedactWriterForTest(&buf, map[string]string{
+			"secret1":      "[REDACTED:A]",
+			"secret1-long": "[REDACTED:B]",
+		})
+
+		writeAndFlush(t, w, "a=secret1 b=secret1-long")
+		gt.Equal(t, buf.String(), "a=[REDACTED:A] b=[REDACTED:B]")
+	})
+
 	t.Run("pass through when no secret matches", func(t *testing.T) {
 		var buf bytes.Buffer
 		w := executor.NewRedactWriterForTest(&buf, []string{"secret"})
@@ -181,3 +199,263 @@ func TestRedactWriter(t *testing.T) {
 		gt.Equal(t, buf.String(), "ok")
 	})
 }
+
+// syncBuffer is a bytes.Buffer safe for the redactWriter flush timer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitForOutput polls buf until it equals want or the timeout expires
+func waitForOutput(t *testing.T, buf *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if buf.String() == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("output = %q, want %q", buf.String(), want)
+}
+
+func TestRedactWriterLatency(t *testing.T) {
+	t.Run("newline writes out bytes that cannot start a secret", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := executor.NewRedactWriterForTest(&buf, []string{"longersecret"})
+
+		gt.R1(w.Write([]byte("ok\n"))).NoError(t)
+		gt.Equal(t, buf.String(), "ok\n")
+	})
+
+	t.Run("newline keeps a possible secret prefix held back", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := executor.NewRedactWriterForTest(&buf, []string{"secret\nvalue"})
+
+		gt.R1(w.Write([]byte("line1\nsecret\n"))).NoError(t)
+		gt.Equal(t, buf.String(), "line1\n")
+
+		gt.R1(w.Write([]byte("value done"))).NoError(t)
+		gt.NoError(t, w.Flush())
+		gt.Equal(t, buf.String(), "line1\n***** done")
+	})
+
+	t.Run("prompt without newline is written after the delay", func(t *testing.T) {
+		var buf syncBuffer
+		w := executor.NewRedactWriterWithDelayForTest(&buf, []string{"my-secret-token"}, 10*time.Millisecond)
+
+		gt.R1(w.Write([]byte("Password: "))).NoError(t)
+		waitForOutput(t, &buf, "Password: ")
+		gt.NoError(t, w.Flush())
+	})
+
+	t.Run("delayed flush holds back a partial secret", func(t *testing.T) {
+		var buf syncBuffer
+		w := executor.NewRedactWriterWithDelayForTest(&buf, []string{"my-secret-token"}, 10*time.Millisecond)
+
+		gt.R1(w.Write([]byte("progress 50% token=my-sec"))).NoError(t)
+		waitForOutput(t, &buf, "progress 50% token=")
+
+		gt.R1(w.Write([]byte("ret-token 100%"))).NoError(t)
+		gt.NoError(t, w.Flush())
+		gt.Equal(t, buf.String(), "progress 50% token=***** 100%")
+	})
+
+	t.Run("no delayed flush when delay is zero", func(t *testing.T) {
+		var buf syncBuffer
+		w := executor.NewRedactWriterWithDelayForTest(&buf, []string{"my-secret-token"}, 0)
+
+		gt.R1(w.Write([]byte("Password: "))).NoError(t)
+		time.Sleep(30 * time.Millisecond)
+		gt.Equal(t, buf.String(), "")
+
+		gt.NoError(t, w.Flush())
+		gt.Equal(t, buf.String(), "Password: ")
+	})
+}
+
+// naiveRedactWriter is the previous implementation of redactWriter, which runs
+// bytes.Index once per secret. It is kept as a reference for equivalence tests
+// and benchmarks.
+type naiveRedactWriter struct {
+	dest         io.Writer
+	secrets      [][]byte
+	maxSecretLen int
+	buf          []byte
+}
+
+func newNaiveRedactWriter(dest io.Writer, secrets []string) *naiveRedactWriter {
+	sorted := make([]string, len(secrets))
+	copy(sorted, secrets)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	w := &naiveRedactWriter{dest: dest}
+	for _, s := range sorted {
+		if len(s) == 0 {
+			continue
+		}
+		w.secrets = append(w.secrets, []byte(s))
+		w.maxSecretLen = max(w.maxSecretLen, len(s))
+	}
+	return w
+}
+
+func (w *naiveRedactWriter) findEarliestMatch(data []byte) (int, int) {
+	bestPos, bestLen := -1, 0
+	for _, secret := range w.secrets {
+		idx := bytes.Index(data, secret)
+		if idx == -1 {
+			continue
+		}
+		if bestPos == -1 || idx < bestPos || (idx == bestPos && len(secret) > bestLen) {
+			bestPos, bestLen = idx, len(secret)
+		}
+	}
+	return bestPos, bestLen
+}
+
+func (w *naiveRedactWriter) Write(p []byte) (int, error) {
+	if w.maxSecretLen == 0 {
+		_, err := w.dest.Write(p)
+		return len(p), err
+	}
+	w.buf = append(w.buf, p...)
+	var output []byte
+	for len(w.buf) >= w.maxSecretLen {
+		pos, length := w.findEarliestMatch(w.buf)
+		if pos == -1 {
+			safeEnd := len(w.buf) - (w.maxSecretLen - 1)
+			output = append(output, w.buf[:safeEnd]...)
+			w.buf = w.buf[safeEnd:]
+			break
+		}
+		output = append(output, w.buf[:pos]...)
+		output = append(output, "*****"...)
+		w.buf = w.buf[pos+length:]
+	}
+	if len(output) > 0 {
+		if _, err := w.dest.Write(output); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *naiveRedactWriter) Flush() error {
+	var output []byte
+	for {
+		pos, length := w.findEarliestMatch(w.buf)
+		if pos == -1 {
+			output = append(output, w.buf...)
+			break
+		}
+		output = append(output, w.buf[:pos]...)
+		output = append(output, "*****"...)
+		w.buf = w.buf[pos+length:]
+	}
+	w.buf = nil
+	_, err := w.dest.Write(output)
+	return err
+}
+
+func TestRedactWriterMatchesNaiveImplementation(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomString := func(alphabet string, n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+
+	// A small alphabet makes overlapping and nested secrets frequent
+	const alphabet = "abc"
+	for i := range 2000 {
+		var secrets []string
+		for range 1 + rnd.Intn(5) {
+			secrets = append(secrets, randomString(alphabet, 1+rnd.Intn(5)))
+		}
+		input := randomString(alphabet, rnd.Intn(60))
+
+		var want bytes.Buffer
+		naive := newNaiveRedactWriter(&want, secrets)
+		gt.R1(naive.Write([]byte(input))).NoError(t)
+		gt.NoError(t, naive.Flush())
+
+		var got bytes.Buffer
+		w := executor.NewRedactWriterForTest(&got, secrets)
+		for rest := input; len(rest) > 0; {
+			n := min(len(rest), 1+rnd.Intn(8))
+			gt.R1(w.Write([]byte(rest[:n]))).NoError(t)
+			rest = rest[n:]
+		}
+		gt.NoError(t, w.Flush())
+
+		if got.String() != want.String() {
+			t.Fatalf("case %d: secrets=%q input=%q: got %q, want %q", i, secrets, input, got.String(), want.String())
+		}
+	}
+}
+
+func BenchmarkRedactWriter(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	var logLines strings.Builder
+	for logLines.Len() < 1<<20 {
+		fmt.Fprintf(&logLines, "2024-01-01T00:00:00Z INFO request handled path=/api/v1/items/%d status=200\n", rnd.Intn(100000))
+	}
+	input := []byte(logLines.String())
+
+	for _, numSecrets := range []int{10, 100, 1000} {
+		secrets := make([]string, numSecrets)
+		for i := range secrets {
+			b := make([]byte, 32)
+			for j := range b {
+				b[j] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			secrets[i] = string(b)
+		}
+
+		type writer interface {
+			Write([]byte) (int, error)
+			Flush() error
+		}
+		impls := []struct {
+			name string
+			new  func() writer
+		}{
+			{"naive", func() writer { return newNaiveRedactWriter(io.Discard, secrets) }},
+			{"automaton", func() writer { return executor.NewRedactWriterForTest(io.Discard, secrets) }},
+		}
+
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%s/secrets=%d", impl.name, numSecrets), func(b *testing.B) {
+				b.SetBytes(int64(len(input)))
+				for b.Loop() {
+					w := impl.new()
+					for chunk := input; len(chunk) > 0; {
+						n := min(len(chunk), 4096)
+						_, _ = w.Write(chunk[:n])
+						chunk = chunk[n:]
+					}
+					_ = w.Flush()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// RetryPolicy decides when a failed command is run again
+type RetryPolicy struct {
+	// Retries is the number of runs after the first one
+	Retries int
+	// Delay is the wait before the first retry
+	Delay time.Duration
+	// Backoff multiplies the delay after each retry. Values below 1 are
+	// treated as 1.
+	Backoff float64
+	// ExitCodes limits retries to these exit codes. Empty retries any failure.
+	ExitCodes []int
+}
+
+// retryable reports whether a run that failed with err is run again
+func (p RetryPolicy) retryable(err error) bool {
+	var execErr *model.ExecutorError
+	if !errors.As(err, &execErr) {
+		return false
+	}
+	return len(p.ExitCodes) == 0 || slices.Contains(p.ExitCodes, execErr.ExitCode())
+}
+
+// NewRetryExecutor wraps exec so that a failed command is run again according
+// to policy. Retrying stops when ctx is cancelled or zenv receives a
+// terminating signal. A final failure is reported with the number of attempts.
+func NewRetryExecutor(exec ExecuteFunc, policy RetryPolicy) ExecuteFunc {
+	return func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+		if policy.Retries <= 0 {
+			return exec(ctx, cmd, args, envVars)
+		}
+		logger := ctxlog.From(ctx)
+		ctx = ContextWithSpawn(ctx, "retry")
+
+		// The executor relays these to the command while it runs; here they
+		// only stop further attempts.
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+		defer signal.Stop(sigCh)
+
+		// A stdin given by the caller is fed again on each attempt if possible
+		stdin, _ := stdinFrom(ctx).(io.Seeker)
+
+		delay := policy.Delay
+		backoff := max(policy.Backoff, 1)
+		attempt := 1
+		for {
+			if stdin != nil {
+				if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+					return model.NewExecutorError(err, 1).WithAttempts(attempt)
+				}
+			}
+
+			err := exec(ctx, cmd, args, envVars)
+			if err == nil {
+				return nil
+			}
+
+			stopped := ctx.Err() != nil
+			select {
+			case <-sigCh:
+				stopped = true
+			default:
+			}
+			if stopped || attempt > policy.Retries || !policy.retryable(err) {
+				return withAttempts(err, attempt)
+			}
+
+			logger.Warn("command failed, retrying", "attempt", attempt, "exit_code", model.GetExitCode(err), "delay", delay)
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-sigCh:
+				timer.Stop()
+				return withAttempts(err, attempt)
+			case <-ctx.Done():
+				timer.Stop()
+				return withAttempts(err, attempt)
+			}
+
+			delay = time.Duration(float64(delay) * backoff)
+			attempt++
+		}
+	}
+}
+
+func withAttempts(err error, attempts int) error {
+	var execErr *model.ExecutorError
+	if errors.As(err, &execErr) {
+		return execErr.WithAttempts(attempts)
+	}
+	return model.NewExecutorError(err, 1).WithAttempts(attempts)
+}
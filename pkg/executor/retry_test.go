@@ -0,0 +1,109 @@
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestTimeoutExecutor(t *testing.T) {
+	t.Run("Stop a command that runs too long", func(t *testing.T) {
+		execFunc := executor.NewTimeoutExecutor(executor.NewDefaultExecutor(executor.WithKillGrace(time.Second)), 100*time.Millisecond)
+
+		start := time.Now()
+		err := execFunc(context.Background(), "sleep", []string{"5"}, nil)
+		gt.True(t, time.Since(start) < 3*time.Second)
+
+		var execErr *model.ExecutorError
+		gt.True(t, errors.As(err, &execErr))
+		gt.True(t, execErr.TimedOut())
+		gt.Equal(t, execErr.ExitCode(), model.TimeoutExitCode)
+	})
+
+	t.Run("Kill a command that ignores SIGTERM", func(t *testing.T) {
+		execFunc := executor.NewTimeoutExecutor(executor.NewDefaultExecutor(executor.WithKillGrace(100*time.Millisecond)), 100*time.Millisecond)
+
+		err := execFunc(context.Background(), "sh", []string{"-c", `trap "" TERM; sleep 5 & wait`}, nil)
+		gt.Equal(t, model.GetExitCode(err), model.TimeoutExitCode)
+	})
+
+	t.Run("Keep the result of a command that finishes in time", func(t *testing.T) {
+		execFunc := executor.NewTimeoutExecutor(executor.NewDefaultExecutor(), 5*time.Second)
+
+		gt.NoError(t, execFunc(context.Background(), "true", nil, nil))
+		gt.Equal(t, model.GetExitCode(execFunc(context.Background(), "sh", []string{"-c", "exit 3"}, nil)), 3)
+	})
+}
+
+func TestRetryExecutor(t *testing.T) {
+	// countRuns returns a script that records each run and exits with code
+	// until it has run okAt times
+	countRuns := func(t *testing.T, code, okAt int) (string, func() int) {
+		counter := filepath.Join(t.TempDir(), "count")
+		script := `echo x >> "` + counter + `"; [ "$(wc -l < "` + counter + `")" -ge ` + strconv.Itoa(okAt) + ` ] || exit ` + strconv.Itoa(code)
+		return script, func() int {
+			data, _ := os.ReadFile(counter)
+			return strings.Count(string(data), "x")
+		}
+	}
+
+	t.Run("Succeed after retries", func(t *testing.T) {
+		script, runs := countRuns(t, 1, 3)
+		execFunc := executor.NewRetryExecutor(executor.NewDefaultExecutor(), executor.RetryPolicy{Retries: 5, Delay: 10 * time.Millisecond})
+
+		gt.NoError(t, execFunc(context.Background(), "sh", []string{"-c", script}, nil))
+		gt.Equal(t, runs(), 3)
+	})
+
+	t.Run("Report attempts and final exit code", func(t *testing.T) {
+		script, runs := countRuns(t, 3, 100)
+		execFunc := executor.NewRetryExecutor(executor.NewDefaultExecutor(), executor.RetryPolicy{Retries: 2, Delay: 10 * time.Millisecond, Backoff: 2})
+
+		err := execFunc(context.Background(), "sh", []string{"-c", script}, nil)
+		var execErr *model.ExecutorError
+		gt.True(t, errors.As(err, &execErr))
+		gt.Equal(t, execErr.Attempts(), 3)
+		gt.Equal(t, execErr.ExitCode(), 3)
+		gt.Equal(t, runs(), 3)
+	})
+
+	t.Run("Retry only listed exit codes", func(t *testing.T) {
+		script, runs := countRuns(t, 3, 100)
+		execFunc := executor.NewRetryExecutor(executor.NewDefaultExecutor(), executor.RetryPolicy{Retries: 2, ExitCodes: []int{4}})
+
+		err := execFunc(context.Background(), "sh", []string{"-c", script}, nil)
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.Equal(t, runs(), 1)
+	})
+
+	t.Run("Feed stdin from context on each attempt", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		execFunc := executor.NewRetryExecutor(executor.NewDefaultExecutor(), executor.RetryPolicy{Retries: 1})
+		ctx := executor.ContextWithStdin(context.Background(), strings.NewReader("input\n"))
+
+		_ = execFunc(ctx, "sh", []string{"-c", `cat >> "$0"; exit 1`, out}, nil)
+		gt.Equal(t, string(gt.R1(os.ReadFile(out)).NoError(t)), "input\ninput\n")
+	})
+
+	t.Run("Retry timed out attempts", func(t *testing.T) {
+		execFunc := executor.NewRetryExecutor(
+			executor.NewTimeoutExecutor(executor.NewDefaultExecutor(executor.WithKillGrace(time.Second)), 50*time.Millisecond),
+			executor.RetryPolicy{Retries: 1, ExitCodes: []int{model.TimeoutExitCode}},
+		)
+
+		err := execFunc(context.Background(), "sleep", []string{"5"}, nil)
+		var execErr *model.ExecutorError
+		gt.True(t, errors.As(err, &execErr))
+		gt.True(t, execErr.TimedOut())
+		gt.Equal(t, execErr.Attempts(), 2)
+	})
+}
@@ -0,0 +1,48 @@
+package executor
+
+import (
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/secretfile"
+)
+
+// materializeFiles writes the value of every AsFile variable to a file in a
+// private directory and returns a copy of envVars in which those variables
+// point to the file path. The returned directory is nil when no variable
+// requested a file; otherwise the caller must remove it.
+func materializeFiles(envVars []*model.EnvVar) ([]*model.EnvVar, *secretfile.Dir, error) {
+	var dir *secretfile.Dir
+	result := make([]*model.EnvVar, 0, len(envVars))
+
+	for _, envVar := range envVars {
+		if !envVar.AsFile {
+			result = append(result, envVar)
+			continue
+		}
+
+		if dir == nil {
+			d, err := secretfile.NewDir()
+			if err != nil {
+				return nil, nil, err
+			}
+			dir = d
+		}
+
+		mode := envVar.FileMode
+		if mode == 0 {
+			mode = model.DefaultSecretFileMode
+		}
+		path, err := dir.Write(envVar.Name, envVar.Value, mode)
+		if err != nil {
+			_ = dir.Remove()
+			return nil, nil, goerr.Wrap(err, "failed to materialize variable as file", goerr.V("name", envVar.Name))
+		}
+
+		fileVar := *envVar
+		fileVar.Value = path
+		fileVar.Secret = false // The path itself reveals nothing
+		result = append(result, &fileVar)
+	}
+
+	return result, dir, nil
+}
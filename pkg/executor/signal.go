@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultKillGrace is how long a child may take to exit after a terminating
+// signal before it is killed with SIGKILL.
+const DefaultKillGrace = 10 * time.Second
+
+type processGroupKey struct{}
+
+// ContextWithProcessGroup returns a context that makes the executor run the
+// command in its own process group, so that stopping it also stops its
+// descendants, even when zenv is in the foreground of a terminal. The command
+// then cannot read from the terminal.
+func ContextWithProcessGroup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, processGroupKey{}, true)
+}
+
+func ownProcessGroupFrom(ctx context.Context) bool {
+	own, _ := ctx.Value(processGroupKey{}).(bool)
+	return own
+}
+
+// runWithSignalRelay runs the command while relaying signals received by zenv
+// to its process group, so that zenv outlives the child and reports its exit
+// status. After a terminating signal or cancellation of ctx, the child is
+// killed if it has not exited within grace; zero grace never kills it.
+// started, if not nil, is called once the command has started.
+func runWithSignalRelay(ctx context.Context, command *exec.Cmd, group *processGroup, grace time.Duration, started func()) error {
+	sigCh := make(chan os.Signal, len(forwardedSignals))
+	signal.Notify(sigCh, forwardedSignals...)
+	defer signal.Stop(sigCh)
+
+	if err := command.Start(); err != nil {
+		return err
+	}
+	group.pid = command.Process.Pid
+	if started != nil {
+		started()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var killTimer *time.Timer
+		defer func() {
+			if killTimer != nil {
+				killTimer.Stop()
+			}
+		}()
+		terminate := func(sig os.Signal) {
+			group.signal(sig)
+			if grace > 0 && killTimer == nil {
+				killTimer = time.AfterFunc(grace, func() { group.signal(syscall.SIGKILL) })
+			}
+		}
+
+		ctxDone := ctx.Done()
+		for {
+			select {
+			case sig := <-sigCh:
+				if isTerminating(sig) {
+					terminate(sig)
+				} else {
+					group.signal(sig)
+				}
+			case <-ctxDone:
+				ctxDone = nil
+				terminate(syscall.SIGTERM)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return command.Wait()
+}
@@ -0,0 +1,42 @@
+//go:build !unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are relayed from zenv to the child
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+func isTerminating(sig os.Signal) bool {
+	return true
+}
+
+// processGroup controls how signals reach the child. Without process groups
+// and signals, the child can only be killed.
+type processGroup struct {
+	pid int
+}
+
+func newProcessGroup(command *exec.Cmd, own bool) *processGroup {
+	return &processGroup{}
+}
+
+func newSessionGroup() *processGroup {
+	return &processGroup{}
+}
+
+func (g *processGroup) signal(sig os.Signal) {
+	if g.pid == 0 {
+		return
+	}
+	// The console already delivered Ctrl-C to the child
+	if sig == os.Interrupt {
+		return
+	}
+	if p, err := os.FindProcess(g.pid); err == nil {
+		_ = p.Kill()
+	}
+}
@@ -0,0 +1,91 @@
+//go:build unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwardedSignals are relayed from zenv to the child
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP,
+	syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2,
+}
+
+// isTerminating reports whether sig asks the child to exit, which starts the
+// grace period before SIGKILL.
+func isTerminating(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT:
+		return true
+	}
+	return false
+}
+
+// isTerminalGenerated reports whether sig is sent by the terminal to the
+// whole foreground process group (Ctrl-C, Ctrl-\).
+func isTerminalGenerated(sig os.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGQUIT
+}
+
+// processGroup controls how signals reach the child.
+type processGroup struct {
+	pid int
+	// own is true when the child leads its own process group
+	own bool
+}
+
+// newProcessGroup prepares command to be signalled as a group. When zenv is in
+// the foreground of its controlling terminal the child stays in zenv's group,
+// so that it can read from and configure the terminal even when stdin is
+// redirected; otherwise, or when own is set, it gets its own group, so that
+// signals also reach its descendants.
+func newProcessGroup(command *exec.Cmd, own bool) *processGroup {
+	g := &processGroup{own: own || !inForeground()}
+	if g.own {
+		if command.SysProcAttr == nil {
+			command.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		command.SysProcAttr.Setpgid = true
+	}
+	return g
+}
+
+// inForeground reports whether zenv's process group is the foreground group of
+// its controlling terminal
+func inForeground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false // no controlling terminal
+	}
+	defer func() { _ = tty.Close() }()
+
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == syscall.Getpgrp()
+}
+
+// newSessionGroup returns the group of a child that leads a new session, so
+// it is signalled as a group
+func newSessionGroup() *processGroup {
+	return &processGroup{own: true}
+}
+
+func (g *processGroup) signal(sig os.Signal) {
+	s, ok := sig.(syscall.Signal)
+	if !ok || g.pid == 0 {
+		return
+	}
+	if g.own {
+		_ = syscall.Kill(-g.pid, s)
+		return
+	}
+	// The terminal already delivered these to the child in the same group
+	if isTerminalGenerated(sig) {
+		return
+	}
+	_ = syscall.Kill(g.pid, s)
+}
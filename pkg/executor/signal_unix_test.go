@@ -0,0 +1,134 @@
+//go:build unix
+
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestDefaultExecutorSignals(t *testing.T) {
+	// waitForFile blocks until the child signals readiness by creating path
+	waitForFile := func(t *testing.T, path string) {
+		t.Helper()
+		for range 100 {
+			if _, err := os.Stat(path); err == nil {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatal("child did not become ready")
+	}
+
+	t.Run("Report 128+signal for a child killed by a signal", func(t *testing.T) {
+		execFunc := executor.NewDefaultExecutor()
+		err := execFunc(context.Background(), "sh", []string{"-c", "kill -TERM $$"}, nil)
+
+		var execErr *model.ExecutorError
+		gt.True(t, errors.As(err, &execErr))
+		gt.Equal(t, execErr.ExitCode(), 128+int(syscall.SIGTERM))
+		gt.Equal(t, execErr.Signal(), syscall.SIGTERM)
+	})
+
+	t.Run("Forward SIGUSR1 to the child", func(t *testing.T) {
+		ready := filepath.Join(t.TempDir(), "ready")
+		go func() {
+			waitForFile(t, ready)
+			_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}()
+
+		execFunc := executor.NewDefaultExecutor()
+		err := execFunc(context.Background(), "sh", []string{"-c", `trap "exit 42" USR1; touch "$0"; for i in 1 2 3 4 5 6 7 8 9 10; do sleep 0.5; done`, ready}, nil)
+		gt.Equal(t, model.GetExitCode(err), 42)
+	})
+
+	t.Run("Forward SIGTERM to the whole process group", func(t *testing.T) {
+		dir := t.TempDir()
+		ready := filepath.Join(dir, "ready")
+		go func() {
+			waitForFile(t, ready)
+			_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		}()
+
+		execFunc := executor.NewDefaultExecutor()
+		err := execFunc(context.Background(), "sh", []string{"-c", `sleep 5 & echo $! > "$0.tmp"; mv "$0.tmp" "$0"; wait`, ready}, nil)
+		gt.Error(t, err)
+
+		pid := strings.TrimSpace(string(gt.R1(os.ReadFile(ready)).NoError(t)))
+		// The grandchild is gone or at most a zombie waiting to be reaped
+		for range 20 {
+			stat, err := os.ReadFile("/proc/" + pid + "/stat")
+			if err != nil || strings.Contains(string(stat), ") Z ") {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Error("grandchild is still running")
+	})
+
+	t.Run("Kill the child after the grace period", func(t *testing.T) {
+		ready := filepath.Join(t.TempDir(), "ready")
+		go func() {
+			waitForFile(t, ready)
+			_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		}()
+
+		start := time.Now()
+		execFunc := executor.NewDefaultExecutor(executor.WithKillGrace(200 * time.Millisecond))
+		err := execFunc(context.Background(), "sh", []string{"-c", `trap "" TERM; touch "$0"; exec sleep 5`, ready}, nil)
+
+		gt.Equal(t, model.GetExitCode(err), 128+int(syscall.SIGKILL))
+		gt.True(t, time.Since(start) < 4*time.Second)
+	})
+
+	t.Run("Terminate the child when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		execFunc := executor.NewDefaultExecutor()
+		err := execFunc(ctx, "sh", []string{"-c", "exec sleep 5"}, nil)
+
+		gt.Equal(t, model.GetExitCode(err), 128+int(syscall.SIGTERM))
+		gt.True(t, time.Since(start) < 4*time.Second)
+	})
+
+	t.Run("Remove as_file variable when zenv receives SIGTERM", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+		outDir := t.TempDir()
+		pathFile := filepath.Join(outDir, "path")
+
+		go func() {
+			for range 100 {
+				if _, err := os.Stat(pathFile); err == nil {
+					_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+					return
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}()
+
+		execFunc := executor.NewDefaultExecutor()
+		envVars := []*model.EnvVar{
+			{Name: "CRED_FILE", Value: "file-content", Source: model.SourceYAML, AsFile: true},
+			{Name: "OUT", Value: pathFile, Source: model.SourceInline},
+		}
+
+		err := execFunc(context.Background(), "sh", []string{"-c", `echo -n "$CRED_FILE" > "$OUT"; exec sleep 5`}, envVars)
+		gt.Error(t, err)
+
+		path := gt.R1(os.ReadFile(pathFile)).NoError(t)
+		_, statErr := os.Stat(string(path))
+		gt.True(t, os.IsNotExist(statErr))
+	})
+}
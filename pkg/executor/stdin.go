@@ -0,0 +1,21 @@
+package executor
+
+import (
+	"context"
+	"io"
+)
+
+type stdinKey struct{}
+
+// ContextWithStdin returns a context that makes the executor feed r to the
+// command's stdin instead of zenv's own stdin.
+func ContextWithStdin(ctx context.Context, r io.Reader) context.Context {
+	return context.WithValue(ctx, stdinKey{}, r)
+}
+
+func stdinFrom(ctx context.Context) io.Reader {
+	if r, ok := ctx.Value(stdinKey{}).(io.Reader); ok {
+		return r
+	}
+	return nil
+}
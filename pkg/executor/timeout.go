@@ -0,0 +1,29 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// NewTimeoutExecutor wraps exec so that the command is stopped when it runs
+// longer than timeout. The command is stopped like on cancellation: it gets
+// SIGTERM and is killed after the kill grace period. A timed out command
+// fails with model.TimeoutExitCode.
+func NewTimeoutExecutor(exec ExecuteFunc, timeout time.Duration) ExecuteFunc {
+	return func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+		ctx, cancel := context.WithTimeout(ContextWithSpawn(ctx, "timeout"), timeout)
+		defer cancel()
+
+		err := exec(ctx, cmd, args, envVars)
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		return model.NewTimeoutExecutorError(err)
+	}
+}
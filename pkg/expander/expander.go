@@ -3,26 +3,53 @@ package expander
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/m-mizutani/ctxlog"
 	"github.com/m-mizutani/goerr/v2"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/secretfile"
 )
 
+// StdinPath is the argument substituted by the asStdin template function
+const StdinPath = "/dev/stdin"
+
+// minLeakedSecretLen is the length below which a secret value is only
+// reported when it is the whole expanded argument, as short values such as
+// "1" or "on" appear in unrelated arguments
+const minLeakedSecretLen = 4
+
 // Expander provides template expansion functionality for command arguments
 type Expander struct {
 	envVars map[string]string
+	secrets map[string]string // secret variable name -> value
+
+	// AllowSecretArgs permits expanded arguments to contain secret values,
+	// which are visible to other users through ps and /proc/<pid>/cmdline
+	AllowSecretArgs bool
+
+	files  *secretfile.Dir
+	nFiles int
+	stdin  *string
 }
 
 // NewExpander creates a new template expander with environment variables
 func NewExpander(envVars []*model.EnvVar) *Expander {
 	envMap := make(map[string]string, len(envVars))
+	secrets := make(map[string]string)
 	for _, ev := range envVars {
 		envMap[ev.Name] = ev.Value
+		if ev.Secret && ev.Value != "" {
+			secrets[ev.Name] = ev.Value
+		} else {
+			delete(secrets, ev.Name)
+		}
 	}
 	return &Expander{
 		envVars: envMap,
+		secrets: secrets,
 	}
 }
 
@@ -31,7 +58,10 @@ func (e *Expander) Expand(ctx context.Context, tmpl string) (string, error) {
 	logger := ctxlog.From(ctx)
 	logger.Debug("expanding template", "template", tmpl)
 
-	t, err := template.New("arg").Option("missingkey=error").Parse(tmpl)
+	t, err := template.New("arg").Option("missingkey=error").Funcs(template.FuncMap{
+		"asFile":  e.asFile,
+		"asStdin": e.asStdin,
+	}).Parse(tmpl)
 	if err != nil {
 		return "", goerr.Wrap(err, "failed to parse template")
 	}
@@ -46,7 +76,9 @@ func (e *Expander) Expand(ctx context.Context, tmpl string) (string, error) {
 	return result, nil
 }
 
-// ExpandArgs expands multiple template strings (command arguments)
+// ExpandArgs expands multiple template strings (command arguments). Unless
+// AllowSecretArgs is set, an argument that gains a secret value through
+// expansion is rejected.
 func (e *Expander) ExpandArgs(ctx context.Context, args []string) ([]string, error) {
 	logger := ctxlog.From(ctx)
 	logger.Debug("expanding template args", "args", args)
@@ -57,9 +89,73 @@ func (e *Expander) ExpandArgs(ctx context.Context, args []string) ([]string, err
 		if err != nil {
 			return nil, goerr.Wrap(err, "failed to expand argument", goerr.V("index", i), goerr.V("arg", arg))
 		}
+		if !e.AllowSecretArgs {
+			if name := e.leakedSecret(arg, result); name != "" {
+				return nil, goerr.New("expanded argument contains a secret value; use asFile or asStdin, or --allow-secret-args",
+					goerr.V("index", i), goerr.V("arg", arg), goerr.V("name", name))
+			}
+		}
 		expanded[i] = result
 	}
 
 	logger.Debug("template args expanded", "expanded", expanded)
 	return expanded, nil
 }
+
+// Stdin returns the value passed to asStdin, if any, to be fed to the command
+func (e *Expander) Stdin() (string, bool) {
+	if e.stdin == nil {
+		return "", false
+	}
+	return *e.stdin, true
+}
+
+// HasFiles reports whether asFile created files that Close must remove
+func (e *Expander) HasFiles() bool {
+	return e.files != nil
+}
+
+// Close removes the files created by asFile
+func (e *Expander) Close() error {
+	if e.files == nil {
+		return nil
+	}
+	return e.files.Remove()
+}
+
+// leakedSecret returns the name of a secret variable whose value appears in
+// the expanded argument but not in the template itself.
+func (e *Expander) leakedSecret(tmpl, result string) string {
+	for name, value := range e.secrets {
+		if len(value) < minLeakedSecretLen && result != value {
+			continue
+		}
+		if strings.Contains(result, value) && !strings.Contains(tmpl, value) {
+			return name
+		}
+	}
+	return ""
+}
+
+// asFile writes value to a private temporary file and returns its path
+func (e *Expander) asFile(value string) (string, error) {
+	if e.files == nil {
+		dir, err := secretfile.NewDir()
+		if err != nil {
+			return "", err
+		}
+		e.files = dir
+	}
+
+	e.nFiles++
+	return e.files.Write(fmt.Sprintf("arg%d", e.nFiles), value, model.DefaultSecretFileMode)
+}
+
+// asStdin arranges for value to be written to the command's stdin and returns StdinPath
+func (e *Expander) asStdin(value string) (string, error) {
+	if e.stdin != nil && *e.stdin != value {
+		return "", goerr.New("asStdin can be used for only one value")
+	}
+	e.stdin = &value
+	return StdinPath, nil
+}
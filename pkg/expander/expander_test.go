@@ -2,6 +2,7 @@ package expander_test
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"github.com/m-mizutani/gt"
@@ -186,3 +187,80 @@ func TestExpander_EmptyEnvVars(t *testing.T) {
 	_, err = exp.Expand(ctx, "{{ .UNDEFINED }}")
 	gt.Error(t, err)
 }
+
+func TestExpander_SecretArgs(t *testing.T) {
+	ctx := context.Background()
+	envVars := []*model.EnvVar{
+		{Name: "USER", Value: "admin"},
+		{Name: "DB_PASSWORD", Value: "hunter2", Secret: true},
+	}
+
+	t.Run("rejects secret value in expanded argument", func(t *testing.T) {
+		exp := expander.NewExpander(envVars)
+		_, err := exp.ExpandArgs(ctx, []string{"--user", "{{ .USER }}", "--password={{ .DB_PASSWORD }}"})
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("secret value")
+		gt.S(t, err.Error()).NotContains("hunter2")
+	})
+
+	t.Run("allows literal argument equal to secret value", func(t *testing.T) {
+		exp := expander.NewExpander(envVars)
+		result := gt.R1(exp.ExpandArgs(ctx, []string{"hunter2"})).NoError(t)
+		gt.Equal(t, result, []string{"hunter2"})
+	})
+
+	t.Run("short secret value only matches the whole argument", func(t *testing.T) {
+		exp := expander.NewExpander([]*model.EnvVar{
+			{Name: "REGION", Value: "us-east-1"},
+			{Name: "DEBUG", Value: "1", Secret: true},
+		})
+		result := gt.R1(exp.ExpandArgs(ctx, []string{"--region={{ .REGION }}"})).NoError(t)
+		gt.Equal(t, result, []string{"--region=us-east-1"})
+
+		_, err := exp.ExpandArgs(ctx, []string{"{{ .DEBUG }}"})
+		gt.Error(t, err)
+	})
+
+	t.Run("allows secret value with AllowSecretArgs", func(t *testing.T) {
+		exp := expander.NewExpander(envVars)
+		exp.AllowSecretArgs = true
+		result := gt.R1(exp.ExpandArgs(ctx, []string{"--password={{ .DB_PASSWORD }}"})).NoError(t)
+		gt.Equal(t, result, []string{"--password=hunter2"})
+	})
+
+	t.Run("asFile substitutes a private file path", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+		exp := expander.NewExpander(envVars)
+
+		gt.False(t, exp.HasFiles())
+		result := gt.R1(exp.ExpandArgs(ctx, []string{"--password-file", "{{ asFile .DB_PASSWORD }}"})).NoError(t)
+		gt.True(t, exp.HasFiles())
+		gt.A(t, result).Length(2)
+		content := gt.R1(os.ReadFile(result[1])).NoError(t)
+		gt.Equal(t, string(content), "hunter2")
+		info := gt.R1(os.Stat(result[1])).NoError(t)
+		gt.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+		gt.NoError(t, exp.Close())
+		_, err := os.Stat(result[1])
+		gt.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("asStdin substitutes stdin", func(t *testing.T) {
+		exp := expander.NewExpander(envVars)
+		_, ok := exp.Stdin()
+		gt.False(t, ok)
+
+		result := gt.R1(exp.ExpandArgs(ctx, []string{"--password-stdin", "{{ asStdin .DB_PASSWORD }}"})).NoError(t)
+		gt.Equal(t, result, []string{"--password-stdin", expander.StdinPath})
+		stdin, ok := exp.Stdin()
+		gt.True(t, ok)
+		gt.Equal(t, stdin, "hunter2")
+	})
+
+	t.Run("asStdin accepts only one value", func(t *testing.T) {
+		exp := expander.NewExpander(envVars)
+		_, err := exp.ExpandArgs(ctx, []string{"{{ asStdin .DB_PASSWORD }}", "{{ asStdin .USER }}"})
+		gt.Error(t, err)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/m-mizutani/ctxlog"
@@ -12,10 +13,24 @@ import (
 	"github.com/m-mizutani/zenv/v2/pkg/model"
 )
 
+// secretMarker is the annotation that marks a .env entry as secret, either on
+// the line before the entry or at the end of the entry line.
+const secretMarker = "zenv:secret"
+
+// secretMarkerSuffix matches a trailing "# zenv:secret" annotation
+var secretMarkerSuffix = regexp.MustCompile(`\s+#\s*` + regexp.QuoteMeta(secretMarker) + `$`)
+
+// isSecretMarkerComment reports whether line is a "# zenv:secret" comment line
+func isSecretMarkerComment(line string) bool {
+	comment, ok := strings.CutPrefix(line, "#")
+	return ok && strings.TrimSpace(comment) == secretMarker
+}
+
 func NewDotEnvLoader(path string) LoadFunc {
 	return func(ctx context.Context) ([]*model.EnvVar, error) {
 		logger := ctxlog.From(ctx)
 		logger.Debug("loading .env file", "path", path)
+		fileRecorderFrom(ctx).Add(path)
 
 		file, err := os.Open(filepath.Clean(path))
 		if err != nil {
@@ -32,12 +47,23 @@ func NewDotEnvLoader(path string) LoadFunc {
 		scanner := bufio.NewScanner(file)
 		lineNumber := 0
 
+		markedSecret := false
 		for scanner.Scan() {
 			lineNumber++
 			line := strings.TrimSpace(scanner.Text())
 
-			// Skip empty lines and comments
-			if line == "" || strings.HasPrefix(line, "#") {
+			// A "# zenv:secret" comment applies to the next entry
+			if isSecretMarkerComment(line) {
+				markedSecret = true
+				continue
+			}
+
+			// Skip empty lines and comments; an empty line detaches a pending marker
+			if line == "" {
+				markedSecret = false
+				continue
+			}
+			if strings.HasPrefix(line, "#") {
 				continue
 			}
 
@@ -53,6 +79,13 @@ func NewDotEnvLoader(path string) LoadFunc {
 			key := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
 
+			secret := markedSecret
+			markedSecret = false
+			if loc := secretMarkerSuffix.FindStringIndex(value); loc != nil {
+				value = strings.TrimSpace(value[:loc[0]])
+				secret = true
+			}
+
 			// Remove quotes if present
 			if len(value) >= 2 {
 				if (value[0] == '"' && value[len(value)-1] == '"') ||
@@ -65,6 +98,7 @@ func NewDotEnvLoader(path string) LoadFunc {
 				Name:   key,
 				Value:  value,
 				Source: model.SourceDotEnv,
+				Secret: secret,
 			})
 		}
 
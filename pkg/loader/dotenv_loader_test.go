@@ -98,4 +98,37 @@ KEY2=value2
 
 		gt.Equal(t, len(envVars), 2)
 	})
+
+	t.Run("Secret markers", func(t *testing.T) {
+		tmpFile := gt.R1(os.CreateTemp("", "test*.env")).NoError(t)
+		defer os.Remove(tmpFile.Name())
+
+		content := `# zenv:secret
+DB_PASSWORD=hunter2
+API_KEY="quoted secret" # zenv:secret
+PLAIN=value # not a marker
+#zenv:secret
+
+AFTER_BLANK=visible
+URL=http://host/#zenv:secret`
+
+		gt.R1(tmpFile.WriteString(content)).NoError(t)
+		tmpFile.Close()
+
+		envVars := gt.R1(loader.NewDotEnvLoader(tmpFile.Name())(context.Background())).NoError(t)
+		got := make(map[string]*model.EnvVar)
+		for _, envVar := range envVars {
+			got[envVar.Name] = envVar
+		}
+
+		gt.Equal(t, got["DB_PASSWORD"].Value, "hunter2")
+		gt.True(t, got["DB_PASSWORD"].Secret)
+		gt.Equal(t, got["API_KEY"].Value, "quoted secret")
+		gt.True(t, got["API_KEY"].Secret)
+		gt.Equal(t, got["PLAIN"].Value, "value # not a marker")
+		gt.False(t, got["PLAIN"].Secret)
+		gt.False(t, got["AFTER_BLANK"].Secret)
+		gt.Equal(t, got["URL"].Value, "http://host/#zenv:secret")
+		gt.False(t, got["URL"].Secret)
+	})
 }
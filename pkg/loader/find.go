@@ -37,20 +37,30 @@ func FindFileUpward(startDir string, filenames ...string) string {
 // ResolveDefaultDotEnvPath returns the default .env file path,
 // searching parent directories from the current working directory.
 func ResolveDefaultDotEnvPath() string {
-	return resolveDefault(defaultDotEnvFiles)
+	return resolveDefault("", defaultDotEnvFiles)
+}
+
+// ResolveDefaultDotEnvPathFrom is ResolveDefaultDotEnvPath searching from dir.
+func ResolveDefaultDotEnvPathFrom(dir string) string {
+	return resolveDefault(dir, defaultDotEnvFiles)
 }
 
 // ResolveDefaultYAMLPath returns the default YAML config file path,
 // searching parent directories from the current working directory.
 func ResolveDefaultYAMLPath() string {
-	return resolveDefault(defaultYAMLFiles)
+	return resolveDefault("", defaultYAMLFiles)
+}
+
+// ResolveDefaultYAMLPathFrom is ResolveDefaultYAMLPath searching from dir.
+func ResolveDefaultYAMLPathFrom(dir string) string {
+	return resolveDefault(dir, defaultYAMLFiles)
 }
 
 // ResolveDefaultHCLPath returns the default HCL config file path,
 // searching parent directories from the current working directory.
 // Returns the fallback filename if not found.
 func ResolveDefaultHCLPath() string {
-	return resolveDefault(defaultHCLFiles)
+	return resolveDefault("", defaultHCLFiles)
 }
 
 // FindDefaultHCLPath returns the discovered HCL config file path, or
@@ -63,13 +73,27 @@ func FindDefaultHCLPath() string {
 	return FindFileUpward(wd, defaultHCLFiles...)
 }
 
-func resolveDefault(filenames []string) string {
-	wd, err := os.Getwd()
-	if err != nil {
-		return filenames[0]
+// FindDefaultHCLPathFrom is FindDefaultHCLPath searching from dir.
+func FindDefaultHCLPathFrom(dir string) string {
+	return FindFileUpward(dir, defaultHCLFiles...)
+}
+
+// resolveDefault searches filenames upward from dir, or from the working
+// directory if dir is empty. If none is found, the first filename in dir is returned.
+func resolveDefault(dir string, filenames []string) string {
+	start := dir
+	if start == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return filenames[0]
+		}
+		start = wd
 	}
-	if found := FindFileUpward(wd, filenames...); found != "" {
+	if found := FindFileUpward(start, filenames...); found != "" {
 		return found
 	}
-	return filenames[0]
+	if dir == "" {
+		return filenames[0]
+	}
+	return filepath.Join(dir, filenames[0])
 }
@@ -81,3 +81,21 @@ func TestFindFileUpward(t *testing.T) {
 		gt.Value(t, result).Equal("")
 	})
 }
+
+func TestResolveDefaultPathFrom(t *testing.T) {
+	t.Run("found upward from dir", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		target := filepath.Join(tmpDir, ".env")
+		gt.NoError(t, os.WriteFile(target, nil, 0o600))
+		child := filepath.Join(tmpDir, "sub")
+		gt.NoError(t, os.Mkdir(child, 0o755))
+
+		gt.Value(t, loader.ResolveDefaultDotEnvPathFrom(child)).Equal(target)
+	})
+
+	t.Run("fallback is placed in dir", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		gt.Value(t, loader.ResolveDefaultYAMLPathFrom(tmpDir)).Equal(filepath.Join(tmpDir, ".env.yaml"))
+		gt.Value(t, loader.FindDefaultHCLPathFrom(tmpDir)).Equal("")
+	})
+}
@@ -39,6 +39,7 @@ func NewHCLLoaderWithProfile(path string, profile string, existingVars ...[]*mod
 		// Reuse the YAML resolver since the in-memory representation is identical.
 		baseDir := filepath.Dir(path)
 		resolver := newYAMLUnifiedResolverWithProfileAndVars(config, profile, baseDir, allExistingVars)
+		resolver.recorder = fileRecorderFrom(ctx)
 
 		var envVars []*model.EnvVar
 		for key, value := range config {
@@ -61,11 +62,18 @@ func NewHCLLoaderWithProfile(path string, profile string, existingVars ...[]*mod
 				return nil, goerr.Wrap(err, "failed to resolve variable", goerr.V("key", key))
 			}
 
+			fileMode, err := resolveFileMode(&value, effectiveValue)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid configuration", goerr.V("key", key))
+			}
+
 			envVars = append(envVars, &model.EnvVar{
-				Name:   key,
-				Value:  resolvedValue,
-				Source: model.SourceHCL,
-				Secret: value.Secret || effectiveValue.Secret,
+				Name:     key,
+				Value:    resolvedValue,
+				Source:   model.SourceHCL,
+				Secret:   value.Secret || effectiveValue.Secret,
+				AsFile:   value.AsFile || effectiveValue.AsFile,
+				FileMode: fileMode,
 			})
 		}
 
@@ -75,7 +83,17 @@ func NewHCLLoaderWithProfile(path string, profile string, existingVars ...[]*mod
 }
 
 func loadHCLFile(ctx context.Context, path string) (model.YAMLConfig, error) {
+	body, err := parseHCLFile(ctx, path)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	return parseHCLBody(body)
+}
+
+// parseHCLFile parses the HCL file at path. It returns nil if the file does not exist.
+func parseHCLFile(ctx context.Context, path string) (*hclsyntax.Body, error) {
 	logger := ctxlog.From(ctx)
+	fileRecorderFrom(ctx).Add(path)
 
 	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
@@ -103,16 +121,23 @@ func loadHCLFile(ctx context.Context, path string) (model.YAMLConfig, error) {
 		return nil, goerr.New("unexpected HCL body type", goerr.V("path", path))
 	}
 
-	return parseHCLBody(body)
+	return body, nil
 }
 
 // parseHCLBody converts the top-level body of an HCL file into a YAMLConfig.
 // Attributes (KEY = "value") become scalar variables, and blocks (KEY { ... })
-// become structured variables.
+// become structured variables. Reserved names hold settings and are skipped,
+// unless they hold a variable definition.
 func parseHCLBody(body *hclsyntax.Body) (model.YAMLConfig, error) {
 	config := make(model.YAMLConfig)
 
 	for name, attr := range body.Attributes {
+		if model.IsReservedKey(name) {
+			if model.ReservedKeyShape(name) != model.ScalarSetting {
+				return nil, model.NewReservedKeyError(name)
+			}
+			continue
+		}
 		s, err := evalStringAttr(attr)
 		if err != nil {
 			return nil, goerr.Wrap(err, "failed to read attribute", goerr.V("name", name))
@@ -126,6 +151,12 @@ func parseHCLBody(body *hclsyntax.Body) (model.YAMLConfig, error) {
 
 	for _, block := range body.Blocks {
 		name := block.Type
+		if model.IsReservedKey(name) {
+			if err := checkReservedBlock(block); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		if _, exists := config[name]; exists {
 			return nil, goerr.New("duplicate variable name",
 				goerr.V("name", name))
@@ -146,8 +177,23 @@ func parseHCLBody(body *hclsyntax.Body) (model.YAMLConfig, error) {
 	return config, nil
 }
 
+// checkReservedBlock fails when a block named like a setting is a variable
+// definition. Settings written as blocks hold no variable fields as
+// attributes; tasks are blocks, so a task may be named like one.
+func checkReservedBlock(block *hclsyntax.Block) error {
+	if model.ReservedKeyShape(block.Type) != model.BlockSetting {
+		return model.NewReservedKeyError(block.Type)
+	}
+	for name := range block.Body.Attributes {
+		if model.IsVariableField(name) {
+			return model.NewReservedKeyError(block.Type)
+		}
+	}
+	return nil
+}
+
 // parseValueBlock parses a block body that represents a single environment variable
-// definition (value/file/command/alias/refs/secret/profile).
+// definition (value/file/command/alias/refs/secret/as_file/file_mode/profile).
 func parseValueBlock(body *hclsyntax.Body) (model.YAMLValue, error) {
 	var v model.YAMLValue
 
@@ -189,6 +235,18 @@ func parseValueBlock(body *hclsyntax.Body) (model.YAMLValue, error) {
 				return v, goerr.Wrap(err, "invalid secret attribute")
 			}
 			v.Secret = b
+		case "as_file":
+			b, err := evalBoolAttr(attr)
+			if err != nil {
+				return v, goerr.Wrap(err, "invalid as_file attribute")
+			}
+			v.AsFile = b
+		case "file_mode":
+			s, err := evalStringAttr(attr)
+			if err != nil {
+				return v, goerr.Wrap(err, "invalid file_mode attribute")
+			}
+			v.FileMode = s
 		default:
 			return v, goerr.New("unknown attribute in value block", goerr.V("name", name))
 		}
@@ -308,6 +366,31 @@ func evalStringSliceAttr(attr *hclsyntax.Attribute) ([]string, error) {
 	return result, nil
 }
 
+func evalStringMapAttr(attr *hclsyntax.Attribute) (map[string]string, error) {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, goerr.New("failed to evaluate", goerr.V("diagnostics", diags.Error()))
+	}
+	if val.IsNull() {
+		return nil, nil
+	}
+	t := val.Type()
+	if !t.IsObjectType() && !t.IsMapType() {
+		return nil, goerr.New("expected map of strings", goerr.V("got", t.FriendlyName()))
+	}
+
+	result := make(map[string]string)
+	it := val.ElementIterator()
+	for it.Next() {
+		key, elem := it.Element()
+		if elem.IsNull() || elem.Type() != cty.String {
+			return nil, goerr.New("map value must be string", goerr.V("key", key.AsString()))
+		}
+		result[key.AsString()] = elem.AsString()
+	}
+	return result, nil
+}
+
 func evalBoolAttr(attr *hclsyntax.Attribute) (bool, error) {
 	val, diags := attr.Expr.Value(nil)
 	if diags.HasErrors() {
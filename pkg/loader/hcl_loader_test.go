@@ -130,6 +130,33 @@ FOO {
 	})
 }
 
+func TestHCLLoaderReservedKeyVariable(t *testing.T) {
+	for _, content := range []string{
+		"tasks = \"x\"\n",
+		"mask {\n  value = \"x\"\n}\n",
+		"hooks {\n  command = [\"echo\"]\n}\n",
+	} {
+		path := filepath.Join(t.TempDir(), "reserved.hcl")
+		gt.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+		_, err := loader.NewHCLLoader(path)(context.Background())
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("reserved for a setting")
+	}
+
+	// A task may be named like a variable field
+	path := filepath.Join(t.TempDir(), "tasks.hcl")
+	gt.NoError(t, os.WriteFile(path, []byte("tasks {\n  file {\n    command = [\"ls\"]\n  }\n}\n"), 0600))
+	gt.R1(loader.NewHCLLoader(path)(context.Background())).NoError(t)
+
+	// Profiles are not settings, whatever their name
+	path = filepath.Join(t.TempDir(), "profile.hcl")
+	gt.NoError(t, os.WriteFile(path, []byte("FOO {\n  value = \"x\"\n  profile {\n    tasks {\n      value = \"y\"\n    }\n  }\n}\n"), 0600))
+	envVars := gt.R1(loader.NewHCLLoaderWithProfile(path, "tasks")(context.Background())).NoError(t)
+	gt.A(t, envVars).Length(1)
+	gt.Equal(t, envVars[0].Value, "y")
+}
+
 func TestHCLLoaderConflictingValueTypes(t *testing.T) {
 	tmp := t.TempDir()
 	path := filepath.Join(tmp, "conflict.hcl")
@@ -196,3 +223,15 @@ func envVarMap(vars []*model.EnvVar) map[string]*model.EnvVar {
 	}
 	return m
 }
+
+func TestHCLLoaderAsFile(t *testing.T) {
+	loadFunc := loader.NewHCLLoader("testdata/as_file.hcl")
+	envVars := gt.R1(loadFunc(context.Background())).NoError(t)
+	got := envVarMap(envVars)
+
+	gt.True(t, got["GCP_CREDENTIALS"].AsFile)
+	gt.Equal(t, got["GCP_CREDENTIALS"].FileMode, os.FileMode(0o600))
+	gt.True(t, got["PG_PASSWORD_FILE"].AsFile)
+	gt.Equal(t, got["PG_PASSWORD_FILE"].FileMode, os.FileMode(0o400))
+	gt.False(t, got["APP_NAME"].AsFile)
+}
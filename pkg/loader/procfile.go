@@ -0,0 +1,57 @@
+package loader
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// DefaultProcfile is the Procfile read by zenv start when none is given
+const DefaultProcfile = "Procfile"
+
+var procfileLine = regexp.MustCompile(`^([A-Za-z0-9_.-]+):\s*(.+)$`)
+
+// LoadProcfile reads the processes of a Procfile, one "name: command" per
+// line. Blank lines and lines starting with # are ignored.
+func LoadProcfile(path string) ([]*model.Process, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, goerr.Wrap(err, "failed to open Procfile", goerr.V("path", path))
+	}
+	defer file.Close()
+
+	var procs []*model.Process
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := procfileLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, goerr.New("invalid Procfile line, expected name: command",
+				goerr.V("path", path), goerr.V("line", lineNum))
+		}
+		if seen[m[1]] {
+			return nil, goerr.New("duplicate process in Procfile",
+				goerr.V("path", path), goerr.V("line", lineNum), goerr.V("process", m[1]))
+		}
+		seen[m[1]] = true
+		procs = append(procs, &model.Process{Name: m[1], Command: m[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, goerr.Wrap(err, "failed to read Procfile", goerr.V("path", path))
+	}
+
+	if len(procs) == 0 {
+		return nil, goerr.New("no processes in Procfile", goerr.V("path", path))
+	}
+	return procs, nil
+}
@@ -0,0 +1,52 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestLoadProcfile(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "Procfile")
+		gt.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("reads processes in order", func(t *testing.T) {
+		path := write(t, `# services
+web: bundle exec rails server -p $PORT
+
+worker.1:   sidekiq -C config/sidekiq.yml
+`)
+		procs := gt.R1(loader.LoadProcfile(path)).NoError(t)
+		gt.Equal(t, procs, []*model.Process{
+			{Name: "web", Command: "bundle exec rails server -p $PORT"},
+			{Name: "worker.1", Command: "sidekiq -C config/sidekiq.yml"},
+		})
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, err := loader.LoadProcfile(write(t, "web: server\nnot a process\n"))
+		gt.Error(t, err)
+	})
+
+	t.Run("duplicate process", func(t *testing.T) {
+		_, err := loader.LoadProcfile(write(t, "web: a\nweb: b\n"))
+		gt.Error(t, err)
+	})
+
+	t.Run("empty Procfile", func(t *testing.T) {
+		_, err := loader.LoadProcfile(write(t, "# nothing\n"))
+		gt.Error(t, err)
+	})
+
+	t.Run("missing Procfile", func(t *testing.T) {
+		_, err := loader.LoadProcfile(filepath.Join(t.TempDir(), "Procfile"))
+		gt.Error(t, err)
+	})
+}
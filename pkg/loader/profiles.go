@@ -0,0 +1,25 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// ListProfiles returns the sorted names of the profiles defined in a YAML or
+// HCL configuration file, picked by extension. A missing file has none.
+func ListProfiles(ctx context.Context, path string) ([]string, error) {
+	var config model.YAMLConfig
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".hcl") {
+		config, err = loadHCLFile(ctx, path)
+	} else {
+		config, err = loadAndMergeYAMLFiles(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.Profiles(), nil
+}
@@ -0,0 +1,19 @@
+package loader_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+)
+
+func TestListProfiles(t *testing.T) {
+	ctx := context.Background()
+
+	gt.Equal(t, gt.R1(loader.ListProfiles(ctx, "testdata/profile_basic.yaml")).NoError(t), []string{"dev", "prod", "staging"})
+	gt.Equal(t, gt.R1(loader.ListProfiles(ctx, "testdata/profile.hcl")).NoError(t), []string{"dev", "prod", "staging"})
+	gt.A(t, gt.R1(loader.ListProfiles(ctx, "testdata/valid.yaml")).NoError(t)).Length(0)
+	gt.A(t, gt.R1(loader.ListProfiles(ctx, filepath.Join(t.TempDir(), ".env.yaml"))).NoError(t)).Length(0)
+}
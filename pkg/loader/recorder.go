@@ -0,0 +1,59 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileRecorder collects the files that loaders read or looked for, so that
+// they can be watched for changes. Missing files are recorded too, since
+// creating them changes the environment.
+type FileRecorder struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+// NewFileRecorder creates an empty FileRecorder
+func NewFileRecorder() *FileRecorder {
+	return &FileRecorder{paths: make(map[string]struct{})}
+}
+
+// Add records path. It does nothing on a nil recorder.
+func (r *FileRecorder) Add(path string) {
+	if r == nil {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[path] = struct{}{}
+}
+
+// Paths returns the recorded paths in sorted order
+func (r *FileRecorder) Paths() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths := make([]string, 0, len(r.paths))
+	for p := range r.paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type fileRecorderKey struct{}
+
+// ContextWithFileRecorder returns a context that makes loaders record the
+// files they use in r.
+func ContextWithFileRecorder(ctx context.Context, r *FileRecorder) context.Context {
+	return context.WithValue(ctx, fileRecorderKey{}, r)
+}
+
+func fileRecorderFrom(ctx context.Context) *FileRecorder {
+	r, _ := ctx.Value(fileRecorderKey{}).(*FileRecorder)
+	return r
+}
@@ -0,0 +1,32 @@
+package loader_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+)
+
+func TestFileRecorder(t *testing.T) {
+	dir := t.TempDir()
+	gt.NoError(t, os.WriteFile(filepath.Join(dir, "token.txt"), []byte("abc\n"), 0o600))
+	gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env.yaml"), []byte("TOKEN:\n  file: token.txt\n"), 0o600))
+
+	recorder := loader.NewFileRecorder()
+	ctx := loader.ContextWithFileRecorder(context.Background(), recorder)
+
+	_ = gt.R1(loader.NewDotEnvLoader(filepath.Join(dir, ".env"))(ctx)).NoError(t)
+	envVars := gt.R1(loader.NewYAMLLoader(filepath.Join(dir, ".env.yaml"))(ctx)).NoError(t)
+	gt.A(t, envVars).Length(1)
+
+	// Missing files are recorded, since creating them changes the environment
+	gt.Equal(t, recorder.Paths(), []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.yaml"),
+		filepath.Join(dir, ".env.yml"),
+		filepath.Join(dir, "token.txt"),
+	})
+}
@@ -0,0 +1,305 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadSettings reads the settings declared with reserved top-level keys in a
+// YAML or HCL configuration file, picked by extension. A missing file yields
+// empty settings. A relative workdir is resolved against the file's directory.
+func LoadSettings(ctx context.Context, path string) (*model.Settings, error) {
+	var settings *model.Settings
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".hcl") {
+		settings, err = loadHCLSettings(ctx, path)
+	} else {
+		settings, err = loadYAMLSettings(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Passthrough != nil {
+		if err := settings.Passthrough.Validate(); err != nil {
+			return nil, goerr.Wrap(err, "invalid passthrough setting", goerr.V("path", path))
+		}
+	}
+	if settings.Hooks != nil {
+		if err := settings.Hooks.Validate(); err != nil {
+			return nil, goerr.Wrap(err, "invalid hooks setting", goerr.V("path", path))
+		}
+	}
+	if settings.Workdir != "" && !filepath.IsAbs(settings.Workdir) {
+		settings.Workdir = filepath.Join(filepath.Dir(path), settings.Workdir)
+	}
+	return settings, nil
+}
+
+func loadYAMLSettings(path string) (*model.Settings, error) {
+	yamlPath, ymlPath := yamlPathPair(path)
+	paths := []string{yamlPath}
+	if ymlPath != yamlPath {
+		paths = append(paths, ymlPath)
+	}
+
+	settings := &model.Settings{}
+	for _, p := range paths {
+		data, err := os.ReadFile(p) // #nosec G304 - file path is user provided and expected
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, goerr.Wrap(err, "failed to read YAML file", goerr.V("path", p))
+		}
+
+		var fileSettings model.Settings
+		if err := yaml.Unmarshal(data, &fileSettings); err != nil {
+			return nil, goerr.Wrap(err, "failed to parse settings in YAML file", goerr.V("path", p))
+		}
+		if key := conflictingSetting(settings, &fileSettings); key != "" {
+			return nil, goerr.New("conflicting setting in .yaml and .yml files", goerr.V("key", key))
+		}
+		settings.Merge(&fileSettings)
+	}
+
+	return settings, nil
+}
+
+// conflictingSetting returns the key of a setting declared in both a and b
+func conflictingSetting(a, b *model.Settings) string {
+	switch {
+	case a.Mask != "" && b.Mask != "" && a.Mask != b.Mask:
+		return "mask"
+	case a.Passthrough != nil && b.Passthrough != nil:
+		return "passthrough"
+	case a.Workdir != "" && b.Workdir != "" && a.Workdir != b.Workdir:
+		return "workdir"
+	case a.Hooks != nil && b.Hooks != nil:
+		return "hooks"
+	}
+	for name := range b.Tasks {
+		if _, ok := a.Tasks[name]; ok {
+			return "tasks." + name
+		}
+	}
+	return ""
+}
+
+func loadHCLSettings(ctx context.Context, path string) (*model.Settings, error) {
+	body, err := parseHCLFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &model.Settings{}
+	if body == nil {
+		return settings, nil
+	}
+
+	if attr, ok := body.Attributes["mask"]; ok {
+		s, err := evalStringAttr(attr)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid mask attribute", goerr.V("path", path))
+		}
+		if s != nil {
+			settings.Mask = *s
+		}
+	}
+
+	if attr, ok := body.Attributes["workdir"]; ok {
+		s, err := evalStringAttr(attr)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid workdir attribute", goerr.V("path", path))
+		}
+		if s != nil {
+			settings.Workdir = *s
+		}
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "passthrough":
+			if settings.Passthrough != nil {
+				return nil, goerr.New("multiple passthrough blocks are not allowed", goerr.V("path", path))
+			}
+			passthrough, err := parsePassthroughBlock(block.Body)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid passthrough block", goerr.V("path", path))
+			}
+			settings.Passthrough = passthrough
+
+		case "tasks":
+			if settings.Tasks != nil {
+				return nil, goerr.New("multiple tasks blocks are not allowed", goerr.V("path", path))
+			}
+			tasks, err := parseTasksBlock(block.Body)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid tasks block", goerr.V("path", path))
+			}
+			settings.Tasks = tasks
+
+		case "hooks":
+			if settings.Hooks != nil {
+				return nil, goerr.New("multiple hooks blocks are not allowed", goerr.V("path", path))
+			}
+			hooks, err := parseHooksBlock(block.Body)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid hooks block", goerr.V("path", path))
+			}
+			settings.Hooks = hooks
+		}
+	}
+
+	return settings, nil
+}
+
+// parsePassthroughBlock parses a passthrough { allow = [...], deny = [...] } block body
+func parsePassthroughBlock(body *hclsyntax.Body) (*model.Passthrough, error) {
+	passthrough := &model.Passthrough{}
+	for name, attr := range body.Attributes {
+		patterns, err := evalStringSliceAttr(attr)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid attribute", goerr.V("name", name))
+		}
+		switch name {
+		case "allow":
+			passthrough.Allow = patterns
+		case "deny":
+			passthrough.Deny = patterns
+		default:
+			return nil, goerr.New("unknown attribute in passthrough block", goerr.V("name", name))
+		}
+	}
+	return passthrough, nil
+}
+
+// parseTasksBlock parses a tasks { NAME { ... } ... } block body
+func parseTasksBlock(body *hclsyntax.Body) (model.Tasks, error) {
+	if len(body.Attributes) > 0 {
+		return nil, goerr.New("tasks must be blocks")
+	}
+
+	tasks := make(model.Tasks)
+	for _, block := range body.Blocks {
+		name := block.Type
+		if _, exists := tasks[name]; exists {
+			return nil, goerr.New("duplicate task name", goerr.V("task", name))
+		}
+		task, err := parseTaskBlock(block.Body)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid task", goerr.V("task", name))
+		}
+		tasks[name] = task
+	}
+	return tasks, nil
+}
+
+// parseTaskBlock parses the body of a single task
+func parseTaskBlock(body *hclsyntax.Body) (*model.Task, error) {
+	task := &model.Task{}
+	for name, attr := range body.Attributes {
+		var err error
+		switch name {
+		case "description":
+			var s *string
+			if s, err = evalStringAttr(attr); s != nil {
+				task.Description = *s
+			}
+		case "profile":
+			var s *string
+			if s, err = evalStringAttr(attr); s != nil {
+				task.Profile = *s
+			}
+		case "command":
+			task.Command, err = evalStringSliceAttr(attr)
+		case "depends":
+			task.Depends, err = evalStringSliceAttr(attr)
+		case "template":
+			task.Template, err = evalBoolAttr(attr)
+		case "env":
+			task.Env, err = evalStringMapAttr(attr)
+		default:
+			return nil, goerr.New("unknown attribute in task", goerr.V("name", name))
+		}
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid attribute", goerr.V("name", name))
+		}
+	}
+	if len(body.Blocks) > 0 {
+		return nil, goerr.New("blocks are not supported in a task", goerr.V("name", body.Blocks[0].Type))
+	}
+	return task, nil
+}
+
+// parseHooksBlock parses a hooks { before { ... } after { ... } } block body.
+// Repeated before and after blocks run in order.
+func parseHooksBlock(body *hclsyntax.Body) (*model.Hooks, error) {
+	hooks := &model.Hooks{}
+	for name, attr := range body.Attributes {
+		switch name {
+		case "fail_fast":
+			failFast, err := evalBoolAttr(attr)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid attribute", goerr.V("name", name))
+			}
+			hooks.FailFast = &failFast
+		case "always_after":
+			alwaysAfter, err := evalBoolAttr(attr)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid attribute", goerr.V("name", name))
+			}
+			hooks.AlwaysAfter = alwaysAfter
+		default:
+			return nil, goerr.New("unknown attribute in hooks block", goerr.V("name", name))
+		}
+	}
+
+	for _, block := range body.Blocks {
+		hook, err := parseHookBlock(block.Body)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid hook", goerr.V("hook", block.Type))
+		}
+		switch block.Type {
+		case "before":
+			hooks.Before = append(hooks.Before, hook)
+		case "after":
+			hooks.After = append(hooks.After, hook)
+		default:
+			return nil, goerr.New("unknown block in hooks block", goerr.V("name", block.Type))
+		}
+	}
+	return hooks, nil
+}
+
+// parseHookBlock parses the body of a single before or after hook
+func parseHookBlock(body *hclsyntax.Body) (*model.Hook, error) {
+	hook := &model.Hook{}
+	for name, attr := range body.Attributes {
+		var err error
+		switch name {
+		case "command":
+			hook.Command, err = evalStringSliceAttr(attr)
+		case "export":
+			hook.Export, err = evalBoolAttr(attr)
+		case "secret":
+			hook.Secret, err = evalBoolAttr(attr)
+		default:
+			return nil, goerr.New("unknown attribute in hook", goerr.V("name", name))
+		}
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid attribute", goerr.V("name", name))
+		}
+	}
+	if len(body.Blocks) > 0 {
+		return nil, goerr.New("blocks are not supported in a hook", goerr.V("name", body.Blocks[0].Type))
+	}
+	return hook, nil
+}
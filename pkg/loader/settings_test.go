@@ -0,0 +1,121 @@
+package loader_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestLoadSettings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("YAML settings are not variables", func(t *testing.T) {
+		settings := gt.R1(loader.LoadSettings(ctx, "testdata/settings.yaml")).NoError(t)
+		gt.Equal(t, settings.Mask, "partial:6")
+		gt.Equal(t, settings.Passthrough.Allow, []string{"PATH", "HOME", "LC_*"})
+		gt.Equal(t, settings.Passthrough.Deny, []string{"LC_SECRET"})
+
+		envVars := gt.R1(loader.NewYAMLLoader("testdata/settings.yaml")(ctx)).NoError(t)
+		got := envVarMap(envVars)
+		gt.A(t, envVars).Length(2)
+		gt.Equal(t, got["DB_HOST"].Value, "localhost")
+		gt.True(t, got["DB_PASS"].Secret)
+	})
+
+	t.Run("HCL settings are not variables", func(t *testing.T) {
+		settings := gt.R1(loader.LoadSettings(ctx, "testdata/settings.hcl")).NoError(t)
+		gt.Equal(t, settings.Mask, "name")
+		gt.Equal(t, settings.Passthrough.Allow, []string{"PATH"})
+
+		envVars := gt.R1(loader.NewHCLLoader("testdata/settings.hcl")(ctx)).NoError(t)
+		gt.A(t, envVars).Length(2)
+	})
+
+	t.Run("tasks in YAML and HCL", func(t *testing.T) {
+		want := model.Tasks{
+			"psql": {
+				Description: "Connect to the database",
+				Command:     []string{"psql", "-h", "{{ .DB_HOST }}"},
+				Profile:     "dev",
+				Template:    true,
+				Env:         map[string]string{"PGAPPNAME": "zenv"},
+				Depends:     []string{"migrate"},
+			},
+			"migrate": {Command: []string{"migrate", "up"}},
+		}
+		for _, path := range []string{"testdata/settings.yaml", "testdata/settings.hcl"} {
+			settings := gt.R1(loader.LoadSettings(ctx, path)).NoError(t)
+			gt.Equal(t, settings.Tasks, want)
+		}
+	})
+
+	t.Run("hooks in YAML and HCL", func(t *testing.T) {
+		failFast := false
+		want := &model.Hooks{
+			Before: []*model.Hook{
+				{Command: []string{"vpn-check"}},
+				{Command: []string{"sh", "-c", "echo TOKEN=$(get-token)"}, Export: true, Secret: true},
+			},
+			After:       []*model.Hook{{Command: []string{"cleanup"}}},
+			FailFast:    &failFast,
+			AlwaysAfter: true,
+		}
+		for _, path := range []string{"testdata/settings.yaml", "testdata/settings.hcl"} {
+			settings := gt.R1(loader.LoadSettings(ctx, path)).NoError(t)
+			gt.Equal(t, settings.Hooks, want)
+		}
+	})
+
+	t.Run("after hooks cannot export", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hooks.yaml")
+		gt.NoError(t, os.WriteFile(path, []byte("hooks:\n  after:\n    - command: [\"env\"]\n      export: true\n"), 0o600))
+
+		_, err := loader.LoadSettings(ctx, path)
+		gt.Error(t, err)
+	})
+
+	t.Run("same task in .yaml and .yml", func(t *testing.T) {
+		dir := t.TempDir()
+		gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env.yaml"), []byte("tasks:\n  a:\n    command: [\"true\"]\n"), 0o600))
+		gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env.yml"), []byte("tasks:\n  a:\n    command: [\"false\"]\n"), 0o600))
+
+		_, err := loader.LoadSettings(ctx, filepath.Join(dir, ".env.yaml"))
+		gt.Error(t, err)
+	})
+
+	t.Run("missing file yields empty settings", func(t *testing.T) {
+		settings := gt.R1(loader.LoadSettings(ctx, filepath.Join(t.TempDir(), ".env.yaml"))).NoError(t)
+		gt.Equal(t, settings.Mask, "")
+	})
+
+	t.Run("conflicting .yaml and .yml settings", func(t *testing.T) {
+		dir := t.TempDir()
+		gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env.yaml"), []byte("mask: name\n"), 0o600))
+		gt.NoError(t, os.WriteFile(filepath.Join(dir, ".env.yml"), []byte("mask: hash\n"), 0o600))
+
+		_, err := loader.LoadSettings(ctx, filepath.Join(dir, ".env.yaml"))
+		gt.Error(t, err)
+	})
+
+	t.Run("invalid passthrough pattern", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env.yaml")
+		gt.NoError(t, os.WriteFile(path, []byte("passthrough:\n  allow: [\"[\"]\n"), 0o600))
+
+		_, err := loader.LoadSettings(ctx, path)
+		gt.Error(t, err)
+	})
+
+	t.Run("relative workdir is resolved against the config directory", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".env.yaml")
+		gt.NoError(t, os.WriteFile(path, []byte("workdir: app\n"), 0o600))
+
+		settings := gt.R1(loader.LoadSettings(ctx, path)).NoError(t)
+		gt.Equal(t, settings.Workdir, filepath.Join(dir, "app"))
+	})
+}
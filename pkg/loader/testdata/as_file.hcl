@@ -0,0 +1,13 @@
+GCP_CREDENTIALS {
+  value   = "{\"type\": \"service_account\"}"
+  secret  = true
+  as_file = true
+}
+
+PG_PASSWORD_FILE {
+  value     = "pg-secret"
+  as_file   = true
+  file_mode = "0400"
+}
+
+APP_NAME = "my-app"
@@ -0,0 +1,42 @@
+mask = "name"
+
+passthrough {
+  allow = ["PATH"]
+}
+
+DB_HOST = "localhost"
+
+DB_PASS {
+  value  = "secret"
+  secret = true
+}
+
+tasks {
+  psql {
+    description = "Connect to the database"
+    command     = ["psql", "-h", "{{ .DB_HOST }}"]
+    profile     = "dev"
+    template    = true
+    env         = { PGAPPNAME = "zenv" }
+    depends     = ["migrate"]
+  }
+  migrate {
+    command = ["migrate", "up"]
+  }
+}
+
+hooks {
+  fail_fast    = false
+  always_after = true
+  before {
+    command = ["vpn-check"]
+  }
+  before {
+    command = ["sh", "-c", "echo TOKEN=$(get-token)"]
+    export  = true
+    secret  = true
+  }
+  after {
+    command = ["cleanup"]
+  }
+}
@@ -0,0 +1,63 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// CommandFiles returns the files of the YAML or HCL configuration at path,
+// picked by extension, that run commands when zenv uses them: files with
+// command sources or hooks. For YAML, path and its .yaml/.yml counterpart are
+// checked separately. Missing files are skipped.
+func CommandFiles(ctx context.Context, path string) ([]string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".hcl") {
+		config, err := loadHCLFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		settings, err := loadHCLSettings(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if config.HasCommand() || settings.Hooks != nil {
+			return []string{path}, nil
+		}
+		return nil, nil
+	}
+
+	yamlPath, ymlPath := yamlPathPair(path)
+	paths := []string{yamlPath}
+	if ymlPath != yamlPath {
+		paths = append(paths, ymlPath)
+	}
+
+	var files []string
+	for _, p := range paths {
+		data, err := os.ReadFile(p) // #nosec G304 - file path is user provided and expected
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, goerr.Wrap(err, "failed to read YAML file", goerr.V("path", p))
+		}
+
+		var config model.YAMLConfig
+		var settings model.Settings
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, goerr.Wrap(err, "failed to parse YAML file", goerr.V("path", p))
+		}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, goerr.Wrap(err, "failed to parse settings in YAML file", goerr.V("path", p))
+		}
+		if config.HasCommand() || settings.Hooks != nil {
+			files = append(files, p)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,35 @@
+package loader_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+)
+
+func TestCommandFiles(t *testing.T) {
+	ctx := context.Background()
+
+	for _, path := range []string{
+		"testdata/with_command.yaml",
+		"testdata/settings.yaml", // hooks
+		"testdata/basic.hcl",
+		"testdata/settings.hcl", // hooks
+	} {
+		gt.Equal(t, gt.R1(loader.CommandFiles(ctx, path)).NoError(t), []string{path})
+	}
+	gt.A(t, gt.R1(loader.CommandFiles(ctx, "testdata/valid.yaml")).NoError(t)).Length(0)
+
+	t.Run("YAML pair and profiles", func(t *testing.T) {
+		dir := t.TempDir()
+		yamlPath, ymlPath := filepath.Join(dir, ".env.yaml"), filepath.Join(dir, ".env.yml")
+		gt.NoError(t, os.WriteFile(yamlPath, []byte("HOST: localhost\n"), 0o600))
+		gt.A(t, gt.R1(loader.CommandFiles(ctx, yamlPath)).NoError(t)).Length(0)
+
+		gt.NoError(t, os.WriteFile(ymlPath, []byte("TOKEN:\n  value: dev\n  profile:\n    prod:\n      command: [\"vault\", \"read\"]\n"), 0o600))
+		gt.Equal(t, gt.R1(loader.CommandFiles(ctx, yamlPath)).NoError(t), []string{ymlPath})
+	})
+}
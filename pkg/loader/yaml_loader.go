@@ -44,6 +44,7 @@ func NewYAMLLoaderWithProfile(path string, profile string, existingVars ...[]*mo
 		// Create unified resolver with existing variables
 		baseDir := filepath.Dir(path)
 		resolver := newYAMLUnifiedResolverWithProfileAndVars(config, profile, baseDir, allExistingVars)
+		resolver.recorder = fileRecorderFrom(ctx)
 
 		// Resolve all variables
 		var envVars []*model.EnvVar
@@ -70,11 +71,18 @@ func NewYAMLLoaderWithProfile(path string, profile string, existingVars ...[]*mo
 					goerr.V("key", key))
 			}
 
+			fileMode, err := resolveFileMode(&value, effectiveValue)
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid configuration", goerr.V("key", key))
+			}
+
 			envVar := &model.EnvVar{
-				Name:   key,
-				Value:  resolvedValue,
-				Source: model.SourceYAML,
-				Secret: value.Secret || effectiveValue.Secret,
+				Name:     key,
+				Value:    resolvedValue,
+				Source:   model.SourceYAML,
+				Secret:   value.Secret || effectiveValue.Secret,
+				AsFile:   value.AsFile || effectiveValue.AsFile,
+				FileMode: fileMode,
 			}
 			envVars = append(envVars, envVar)
 		}
@@ -90,6 +98,7 @@ func loadAndMergeYAMLFiles(ctx context.Context, path string) (model.YAMLConfig,
 
 	// Helper function to load a single YAML file
 	loadOneFile := func(filePath string) (model.YAMLConfig, bool, error) {
+		fileRecorderFrom(ctx).Add(filePath)
 		if _, err := os.Stat(filePath); err != nil {
 			if os.IsNotExist(err) {
 				return nil, false, nil // File not found is acceptable
@@ -113,14 +122,7 @@ func loadAndMergeYAMLFiles(ctx context.Context, path string) (model.YAMLConfig,
 		return config, true, nil
 	}
 
-	// Determine base path and construct both .yaml and .yml paths
-	base := path
-	ext := filepath.Ext(path)
-	if ext == ".yaml" || ext == ".yml" {
-		base = strings.TrimSuffix(path, ext)
-	}
-	yamlPath := base + ".yaml"
-	ymlPath := base + ".yml"
+	yamlPath, ymlPath := yamlPathPair(path)
 
 	// Load .env.yaml
 	config1, found1, err1 := loadOneFile(yamlPath)
@@ -166,6 +168,16 @@ func loadAndMergeYAMLFiles(ctx context.Context, path string) (model.YAMLConfig,
 	return merged, nil
 }
 
+// yamlPathPair returns the .yaml and .yml variants of path, which are loaded together
+func yamlPathPair(path string) (string, string) {
+	base := path
+	ext := filepath.Ext(path)
+	if ext == ".yaml" || ext == ".yml" {
+		base = strings.TrimSuffix(path, ext)
+	}
+	return base + ".yaml", base + ".yml"
+}
+
 // mergeYAMLConfigs merges two YAML configurations with field-level conflict detection
 func mergeYAMLConfigs(config1, config2 model.YAMLConfig) (model.YAMLConfig, error) {
 	result := make(model.YAMLConfig)
@@ -271,6 +283,19 @@ func mergeYAMLValues(key string, v1, v2 model.YAMLValue) (model.YAMLValue, error
 	// Merge secret flag (true if either is true)
 	merged.Secret = v1.Secret || v2.Secret
 
+	// Merge as_file flag (true if either is true); file_mode from whichever has it
+	merged.AsFile = v1.AsFile || v2.AsFile
+	if v1.FileMode != nil && v2.FileMode != nil && *v1.FileMode != *v2.FileMode {
+		return model.YAMLValue{}, goerr.New(
+			fmt.Sprintf("conflicting field \"file_mode\" for environment variable \"%s\" found in both .env.yaml and .env.yml", key),
+		)
+	}
+	if v1.FileMode != nil {
+		merged.FileMode = v1.FileMode
+	} else {
+		merged.FileMode = v2.FileMode
+	}
+
 	// Merge profiles (v2 overrides v1 for same profile names)
 	if len(v1.Profile) > 0 || len(v2.Profile) > 0 {
 		merged.Profile = make(map[string]*model.YAMLValue)
@@ -285,6 +310,15 @@ func mergeYAMLValues(key string, v1, v2 model.YAMLValue) (model.YAMLValue, error
 	return merged, nil
 }
 
+// resolveFileMode returns the as_file permission for a variable. A file_mode set
+// on the profile value takes precedence over the one on the base value.
+func resolveFileMode(base, effective *model.YAMLValue) (os.FileMode, error) {
+	if effective.FileMode != nil {
+		return effective.ParseFileMode()
+	}
+	return base.ParseFileMode()
+}
+
 func readYAMLFile(path string) (string, error) {
 	content, err := os.ReadFile(path) // #nosec G304 - file path is user provided and expected
 	if err != nil {
@@ -313,6 +347,7 @@ type yamlUnifiedResolver struct {
 	resolvedVars map[string]string
 	resolving    map[string]bool   // Track variables currently being resolved
 	externalVars map[string]string // Variables from .env files, system environment, and other sources
+	recorder     *FileRecorder     // Records files read by file sources, if set
 }
 
 func newYAMLUnifiedResolverWithProfileAndVars(config model.YAMLConfig, profile string, baseDir string, existingVars []*model.EnvVar) *yamlUnifiedResolver {
@@ -437,6 +472,7 @@ func (r *yamlUnifiedResolver) resolveWithValue(key string, config *model.YAMLVal
 		if !filepath.IsAbs(filePath) && r.baseDir != "" {
 			filePath = filepath.Join(r.baseDir, filePath)
 		}
+		r.recorder.Add(filePath)
 		resolvedValue, err = readYAMLFile(filePath)
 		if err != nil {
 			return "", goerr.Wrap(err, "failed to read file",
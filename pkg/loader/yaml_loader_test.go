@@ -1775,3 +1775,49 @@ func TestYAMLLoaderSecret(t *testing.T) {
 		gt.Equal(t, varMap["API_KEY"].Secret, false)
 	})
 }
+
+func TestYAMLLoaderAsFile(t *testing.T) {
+	t.Run("as_file and file_mode are propagated to EnvVar", func(t *testing.T) {
+		loadFunc := loader.NewYAMLLoader("testdata/as_file.yaml")
+		envVars := gt.R1(loadFunc(context.Background())).NoError(t)
+
+		varMap := make(map[string]*model.EnvVar)
+		for _, v := range envVars {
+			varMap[v.Name] = v
+		}
+
+		gt.True(t, varMap["GCP_CREDENTIALS"].AsFile)
+		gt.True(t, varMap["GCP_CREDENTIALS"].Secret)
+		gt.Equal(t, varMap["GCP_CREDENTIALS"].FileMode, os.FileMode(0o600))
+		gt.True(t, varMap["PG_PASSWORD_FILE"].AsFile)
+		gt.Equal(t, varMap["PG_PASSWORD_FILE"].FileMode, os.FileMode(0o400))
+		gt.False(t, varMap["APP_NAME"].AsFile)
+	})
+
+	t.Run("as_file is inherited by profile values", func(t *testing.T) {
+		loadFunc := loader.NewYAMLLoaderWithProfile("testdata/as_file.yaml", "dev")
+		envVars := gt.R1(loadFunc(context.Background())).NoError(t)
+
+		varMap := make(map[string]*model.EnvVar)
+		for _, v := range envVars {
+			varMap[v.Name] = v
+		}
+
+		gt.Equal(t, varMap["PG_PASSWORD_FILE"].Value, "dev-secret")
+		gt.True(t, varMap["PG_PASSWORD_FILE"].AsFile)
+		gt.Equal(t, varMap["PG_PASSWORD_FILE"].FileMode, os.FileMode(0o400))
+	})
+
+	t.Run("invalid file_mode is rejected", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		yamlPath := filepath.Join(tmpDir, ".env.yaml")
+		gt.NoError(t, os.WriteFile(yamlPath, []byte(`KEY:
+  value: "x"
+  as_file: true
+  file_mode: "rw-------"
+`), 0600))
+
+		_, err := loader.NewYAMLLoader(yamlPath)(context.Background())
+		gt.Error(t, err)
+	})
+}
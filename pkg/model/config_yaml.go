@@ -1,6 +1,10 @@
 package model
 
 import (
+	"os"
+	"sort"
+	"strconv"
+
 	"github.com/m-mizutani/goerr/v2"
 	"gopkg.in/yaml.v3"
 )
@@ -8,6 +12,65 @@ import (
 // YAMLConfig represents a YAML configuration file with environment variables
 type YAMLConfig map[string]YAMLValue
 
+// UnmarshalYAML implements the yaml.Unmarshaler interface for YAMLConfig.
+// Reserved top-level keys hold Settings and are skipped, unless they hold a
+// variable definition.
+func (c *YAMLConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return goerr.Wrap(err, "failed to decode YAMLConfig")
+	}
+
+	config := make(YAMLConfig, len(raw))
+	for key, valueNode := range raw {
+		if IsReservedKey(key) {
+			if err := checkReservedKey(key, &valueNode); err != nil {
+				return err
+			}
+			continue
+		}
+		var value YAMLValue
+		if err := valueNode.Decode(&value); err != nil {
+			return goerr.Wrap(err, "failed to decode variable", goerr.V("key", key))
+		}
+		config[key] = value
+	}
+	*c = config
+	return nil
+}
+
+// Profiles returns the sorted names of the profiles that variables define
+func (c YAMLConfig) Profiles() []string {
+	seen := make(map[string]bool)
+	var profiles []string
+	for _, value := range c {
+		for name := range value.Profile {
+			if !seen[name] {
+				seen[name] = true
+				profiles = append(profiles, name)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// HasCommand reports whether any variable, in any profile, is read from the
+// output of a command
+func (c YAMLConfig) HasCommand() bool {
+	for _, value := range c {
+		if len(value.Command) > 0 {
+			return true
+		}
+		for _, profileValue := range value.Profile {
+			if profileValue != nil && len(profileValue.Command) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // YAMLValue represents a single environment variable configuration with multiple source options
 type YAMLValue struct {
 	// Value is a direct string value
@@ -22,6 +85,10 @@ type YAMLValue struct {
 	Refs []string `yaml:"refs,omitempty"`
 	// Secret indicates the value should be masked in display output
 	Secret bool `yaml:"secret,omitempty"`
+	// AsFile writes the resolved value to a temporary file and exports its path instead
+	AsFile bool `yaml:"as_file,omitempty"`
+	// FileMode is the octal permission of the file created by AsFile (default 0600)
+	FileMode *string `yaml:"file_mode,omitempty"`
 	// Profile contains profile-specific configurations
 	Profile map[string]*YAMLValue `yaml:"profile,omitempty"`
 }
@@ -84,6 +151,15 @@ func (v YAMLValue) Validate() error {
 		return goerr.New("multiple value types specified (only one of value, file, command, or alias can be specified)")
 	}
 
+	if v.FileMode != nil {
+		if !v.AsFile {
+			return goerr.New("file_mode can only be used with as_file")
+		}
+		if _, err := v.ParseFileMode(); err != nil {
+			return err
+		}
+	}
+
 	// Validate profile values
 	for profileName, profileValue := range v.Profile {
 		if profileValue == nil {
@@ -108,6 +184,20 @@ func (v YAMLValue) Validate() error {
 	return nil
 }
 
+// ParseFileMode returns the permission for the file created by AsFile.
+// FileMode is an octal string such as "0400"; 0600 is used when it is not set.
+func (v *YAMLValue) ParseFileMode() (os.FileMode, error) {
+	if v.FileMode == nil {
+		return DefaultSecretFileMode, nil
+	}
+	mode, err := strconv.ParseUint(*v.FileMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, goerr.New("invalid file_mode (expected octal permission such as 0600)",
+			goerr.V("file_mode", *v.FileMode))
+	}
+	return os.FileMode(mode), nil
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface for YAMLValue.
 // It supports multiple formats:
 // - Direct string: KEY: "value" or dev: "value"
@@ -157,6 +157,31 @@ func TestYAMLValue_Validate(t *testing.T) {
 		gt.NoError(t, v.Validate())
 	})
 
+	t.Run("as_file with file_mode is valid", func(t *testing.T) {
+		value := "test"
+		mode := "0400"
+		v := model.YAMLValue{Value: &value, AsFile: true, FileMode: &mode}
+		gt.NoError(t, v.Validate())
+	})
+
+	t.Run("file_mode without as_file error", func(t *testing.T) {
+		value := "test"
+		mode := "0400"
+		v := model.YAMLValue{Value: &value, FileMode: &mode}
+		err := v.Validate()
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("file_mode can only be used with as_file")
+	})
+
+	t.Run("non-octal file_mode error", func(t *testing.T) {
+		value := "test"
+		mode := "0900"
+		v := model.YAMLValue{Value: &value, AsFile: true, FileMode: &mode}
+		err := v.Validate()
+		gt.Error(t, err)
+		gt.S(t, err.Error()).Contains("invalid file_mode")
+	})
+
 	t.Run("refs without value or command error", func(t *testing.T) {
 		v := model.YAMLValue{Refs: []string{"NAME"}}
 		err := v.Validate()
@@ -3,13 +3,26 @@ package model
 import (
 	"errors"
 	"fmt"
+	"os"
+	"syscall"
 )
 
+// DefaultSecretFileMode is the permission of files materialized for AsFile variables
+const DefaultSecretFileMode os.FileMode = 0o600
+
 type EnvVar struct {
 	Name   string
 	Value  string
 	Source EnvSource
 	Secret bool
+	// SecretReason explains why Secret is set when it was decided by automatic
+	// classification rather than declared by the configuration.
+	SecretReason string
+	// AsFile requests the executor to write Value to a temporary file and
+	// export the file path as the variable instead of the value itself.
+	AsFile bool
+	// FileMode is the permission of the file created for AsFile
+	FileMode os.FileMode
 }
 
 type EnvSource int
@@ -20,23 +33,82 @@ const (
 	SourceYAML
 	SourceInline
 	SourceHCL
+	// SourceHook marks variables exported by a before hook
+	SourceHook
 )
 
+// String returns the label of the source shown in the variable list
+func (s EnvSource) String() string {
+	switch s {
+	case SourceSystem:
+		return "system"
+	case SourceDotEnv:
+		return ".env"
+	case SourceYAML:
+		return ".yaml"
+	case SourceHCL:
+		return ".hcl"
+	case SourceInline:
+		return "inline"
+	case SourceHook:
+		return "hook"
+	}
+	return ""
+}
+
 // ExecutorError represents an error from command execution.
 // When the executed command exits with a non-zero code, its stderr output
 // is already visible to the user, so zenv should not print additional messages.
 type ExecutorError struct {
 	err      error
 	exitCode int
+	signal   syscall.Signal
+	timedOut bool
+	attempts int
 }
 
+// TimeoutExitCode is the exit code of a command stopped by a timeout, as
+// reported by timeout(1)
+const TimeoutExitCode = 124
+
 // NewExecutorError creates a new ExecutorError wrapping the given error
 func NewExecutorError(err error, exitCode int) *ExecutorError {
 	return &ExecutorError{err: err, exitCode: exitCode}
 }
 
+// NewSignaledExecutorError creates an ExecutorError for a command terminated
+// by sig. The exit code is 128+sig, as reported by shells.
+func NewSignaledExecutorError(err error, sig syscall.Signal) *ExecutorError {
+	return &ExecutorError{err: err, exitCode: 128 + int(sig), signal: sig}
+}
+
+// NewTimeoutExecutorError creates an ExecutorError for a command stopped
+// because it ran longer than its timeout
+func NewTimeoutExecutorError(err error) *ExecutorError {
+	return &ExecutorError{err: err, exitCode: TimeoutExitCode, timedOut: true}
+}
+
+// WithAttempts returns a copy of e that records how many times the command was run
+func (e *ExecutorError) WithAttempts(n int) *ExecutorError {
+	c := *e
+	c.attempts = n
+	return &c
+}
+
 func (e *ExecutorError) Error() string {
-	return fmt.Sprintf("command exited with code %d: %v", e.exitCode, e.err)
+	var msg string
+	switch {
+	case e.timedOut:
+		msg = fmt.Sprintf("command timed out (exit code %d): %v", e.exitCode, e.err)
+	case e.signal != 0:
+		msg = fmt.Sprintf("command terminated by signal %s (exit code %d): %v", e.signal, e.exitCode, e.err)
+	default:
+		msg = fmt.Sprintf("command exited with code %d: %v", e.exitCode, e.err)
+	}
+	if e.attempts > 1 {
+		msg += fmt.Sprintf(" (after %d attempts)", e.attempts)
+	}
+	return msg
 }
 
 func (e *ExecutorError) Unwrap() error {
@@ -48,6 +120,24 @@ func (e *ExecutorError) ExitCode() int {
 	return e.exitCode
 }
 
+// Signal returns the signal that terminated the command, or 0 if it exited normally
+func (e *ExecutorError) Signal() syscall.Signal {
+	return e.signal
+}
+
+// TimedOut reports whether the command was stopped by a timeout
+func (e *ExecutorError) TimedOut() bool {
+	return e.timedOut
+}
+
+// Attempts returns how many times the command was run, which is 1 unless it was retried
+func (e *ExecutorError) Attempts() int {
+	if e.attempts == 0 {
+		return 1
+	}
+	return e.attempts
+}
+
 // IsExecutorError checks whether the error originates from command execution
 func IsExecutorError(err error) bool {
 	var execErr *ExecutorError
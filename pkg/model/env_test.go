@@ -3,6 +3,7 @@ package model_test
 import (
 	"errors"
 	"fmt"
+	"syscall"
 	"testing"
 
 	"github.com/m-mizutani/gt"
@@ -18,6 +19,28 @@ func TestExecutorError(t *testing.T) {
 		gt.S(t, err.Error()).Contains("42")
 	})
 
+	t.Run("creates error for a command terminated by a signal", func(t *testing.T) {
+		err := model.NewSignaledExecutorError(errors.New("signal: terminated"), syscall.SIGTERM)
+
+		gt.Equal(t, err.ExitCode(), 143)
+		gt.Equal(t, err.Signal(), syscall.SIGTERM)
+		gt.S(t, err.Error()).Contains("terminated by signal")
+		gt.Equal(t, model.NewExecutorError(errors.New("fail"), 1).Signal(), syscall.Signal(0))
+	})
+
+	t.Run("creates error for a timed out and retried command", func(t *testing.T) {
+		err := model.NewTimeoutExecutorError(errors.New("signal: terminated"))
+		gt.Equal(t, err.ExitCode(), model.TimeoutExitCode)
+		gt.True(t, err.TimedOut())
+		gt.Equal(t, err.Attempts(), 1)
+
+		retried := err.WithAttempts(3)
+		gt.Equal(t, retried.Attempts(), 3)
+		gt.Equal(t, retried.ExitCode(), model.TimeoutExitCode)
+		gt.S(t, retried.Error()).Contains("after 3 attempts")
+		gt.Equal(t, err.Attempts(), 1)
+	})
+
 	t.Run("unwraps to original error", func(t *testing.T) {
 		original := errors.New("command failed")
 		err := model.NewExecutorError(original, 1)
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Hooks are commands run with the resolved environment before and after the
+// command
+type Hooks struct {
+	Before []*Hook `yaml:"before,omitempty"`
+	After  []*Hook `yaml:"after,omitempty"`
+	// FailFast stops at the first failing hook, and a failing before hook
+	// keeps the command from running. Otherwise failures are only reported.
+	// Nil means true.
+	FailFast *bool `yaml:"fail_fast,omitempty"`
+	// AlwaysAfter runs the after hooks even when a before hook or the command
+	// fails
+	AlwaysAfter bool `yaml:"always_after,omitempty"`
+}
+
+// Hook is a single hook command
+type Hook struct {
+	// Command is the command and its arguments
+	Command []string `yaml:"command"`
+	// Export adds the KEY=value lines the hook prints to the environment of
+	// the later hooks and the command. Only for before hooks.
+	Export bool `yaml:"export,omitempty"`
+	// Secret marks the exported variables as secret
+	Secret bool `yaml:"secret,omitempty"`
+}
+
+// IsFailFast reports whether a failing hook stops the run
+func (h *Hooks) IsFailFast() bool {
+	return h.FailFast == nil || *h.FailFast
+}
+
+// Validate checks that every hook has a command and that only before hooks
+// export variables
+func (h *Hooks) Validate() error {
+	for i, hook := range h.Before {
+		if len(hook.Command) == 0 {
+			return goerr.New("hook has no command", goerr.V("hook", "before"), goerr.V("index", i))
+		}
+	}
+	for i, hook := range h.After {
+		if len(hook.Command) == 0 {
+			return goerr.New("hook has no command", goerr.V("hook", "after"), goerr.V("index", i))
+		}
+		if hook.Export || hook.Secret {
+			return goerr.New("after hooks cannot export variables", goerr.V("index", i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package model
+
+// Process is a named command of a Procfile
+type Process struct {
+	Name string
+	// Command is run by sh -c
+	Command string
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Report describes what a zenv run did, for CI systems and wrappers
+type Report struct {
+	// Files are the configuration and .env files that were loaded
+	Files   []string `json:"files"`
+	Profile string   `json:"profile,omitempty"`
+	// Variables are the names of the final variables grouped by source
+	Variables map[string][]ReportVariable `json:"variables"`
+
+	Command         string    `json:"command,omitempty"`
+	Args            []string  `json:"args,omitempty"`
+	StartedAt       time.Time `json:"started_at,omitzero"`
+	EndedAt         time.Time `json:"ended_at,omitzero"`
+	DurationSeconds float64   `json:"duration_seconds"`
+
+	ExitCode int    `json:"exit_code"`
+	Signal   string `json:"signal,omitempty"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+	// Error is set when zenv failed for a reason other than the command's exit status
+	Error string `json:"error,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty"`
+
+	mu sync.Mutex
+}
+
+// ReportVariable is a variable in a Report. Value is set only for
+// non-secret variables and only when values are requested.
+type ReportVariable struct {
+	Name   string  `json:"name"`
+	Value  *string `json:"value,omitempty"`
+	Secret bool    `json:"secret,omitempty"`
+}
+
+// AddWarning records a warning. It is safe for concurrent use.
+func (r *Report) AddWarning(msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Warnings = append(r.Warnings, msg)
+}
+
+// SetResult records how the run ended; err should already be scrubbed of
+// secret values.
+func (r *Report) SetResult(err error) {
+	r.ExitCode = GetExitCode(err)
+
+	var execErr *ExecutorError
+	if errors.As(err, &execErr) {
+		if execErr.Signal() != 0 {
+			r.Signal = execErr.Signal().String()
+		}
+		r.TimedOut = execErr.TimedOut()
+		r.Attempts = execErr.Attempts()
+		return
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
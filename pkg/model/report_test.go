@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestReportSetResult(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var r model.Report
+		r.SetResult(nil)
+		gt.Equal(t, r.ExitCode, 0)
+		gt.Equal(t, r.Error, "")
+	})
+
+	t.Run("signaled command", func(t *testing.T) {
+		var r model.Report
+		r.SetResult(model.NewSignaledExecutorError(errors.New("signal: terminated"), syscall.SIGTERM).WithAttempts(2))
+		gt.Equal(t, r.ExitCode, 143)
+		gt.Equal(t, r.Signal, "terminated")
+		gt.Equal(t, r.Attempts, 2)
+		gt.Equal(t, r.Error, "")
+	})
+
+	t.Run("zenv failure", func(t *testing.T) {
+		var r model.Report
+		r.SetResult(errors.New("failed to load environment variables"))
+		gt.Equal(t, r.ExitCode, 1)
+		gt.Equal(t, r.Error, "failed to load environment variables")
+	})
+}
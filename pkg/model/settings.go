@@ -0,0 +1,182 @@
+package model
+
+import (
+	"path"
+
+	"github.com/m-mizutani/goerr/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings holds zenv options declared in a configuration file with reserved
+// top-level keys, next to the variable definitions.
+type Settings struct {
+	// Mask is the masking policy for secret values, e.g. "name" or "partial:4"
+	Mask string `yaml:"mask,omitempty"`
+	// Passthrough decides which system environment variables reach the command
+	Passthrough *Passthrough `yaml:"passthrough,omitempty"`
+	// Workdir is the directory the command runs in. A relative path is
+	// resolved against the directory of the configuration file.
+	Workdir string `yaml:"workdir,omitempty"`
+	// Tasks are named commands run with `zenv run`
+	Tasks Tasks `yaml:"tasks,omitempty"`
+	// Hooks are commands run before and after the command
+	Hooks *Hooks `yaml:"hooks,omitempty"`
+}
+
+// Passthrough filters system environment variables by name with globs
+// (path.Match syntax). A variable passes when Allow is empty or one of its
+// patterns matches, and no pattern in Deny matches.
+type Passthrough struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// Validate checks that all patterns are valid globs
+func (p *Passthrough) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Allow...), p.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return goerr.Wrap(err, "invalid passthrough pattern", goerr.V("pattern", pattern))
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the system variable name passes the filter. A nil
+// Passthrough allows every variable.
+func (p *Passthrough) Allows(name string) bool {
+	if p == nil {
+		return true
+	}
+	for _, pattern := range p.Deny {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range p.Allow {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingShape is how the value of a reserved key is written
+type SettingShape int
+
+const (
+	// ScalarSetting is a plain value, e.g. mask: name
+	ScalarSetting SettingShape = iota + 1
+	// BlockSetting is a mapping (YAML) or a block (HCL), e.g. hooks: {...}
+	BlockSetting
+)
+
+// reservedKeys are the top-level configuration keys read into Settings. They
+// are not treated as variables.
+var reservedKeys = map[string]SettingShape{
+	"mask":        ScalarSetting,
+	"passthrough": BlockSetting,
+	"workdir":     ScalarSetting,
+	"tasks":       BlockSetting,
+	"hooks":       BlockSetting,
+}
+
+// variableFields are the fields of a variable definition
+var variableFields = map[string]bool{
+	"value": true, "file": true, "command": true, "alias": true, "refs": true,
+	"secret": true, "as_file": true, "file_mode": true, "profile": true,
+}
+
+// IsReservedKey reports whether a top-level configuration key is a setting
+// rather than a variable name.
+func IsReservedKey(key string) bool {
+	return reservedKeys[key] != 0
+}
+
+// ReservedKeyShape returns how the setting of a reserved key is written, or
+// zero if key is not reserved.
+func ReservedKeyShape(key string) SettingShape {
+	return reservedKeys[key]
+}
+
+// IsVariableField reports whether name is a field of a variable definition,
+// such as value or command
+func IsVariableField(name string) bool {
+	return variableFields[name]
+}
+
+// NewReservedKeyError reports a variable named like a setting, which would
+// otherwise be dropped
+func NewReservedKeyError(key string) error {
+	return goerr.New("variable name is reserved for a setting; rename the variable", goerr.V("key", key))
+}
+
+// checkReservedKey fails when the value of a reserved key is shaped like a
+// variable definition rather than the setting
+func checkReservedKey(key string, node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch ReservedKeyShape(key) {
+	case ScalarSetting:
+		if node.Kind != yaml.ScalarNode {
+			return NewReservedKeyError(key)
+		}
+	case BlockSetting:
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			return nil
+		}
+		if node.Kind != yaml.MappingNode {
+			return NewReservedKeyError(key)
+		}
+		// Tasks are mappings, so a task may be named like a variable field
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if IsVariableField(node.Content[i].Value) && node.Content[i+1].Kind != yaml.MappingNode {
+				return NewReservedKeyError(key)
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Settings. It
+// fails when a reserved key holds a variable definition.
+func (s *Settings) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkReservedKey(node.Content[i].Value, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	type plain Settings
+	return node.Decode((*plain)(s))
+}
+
+// Merge overrides the settings with those set in other
+func (s *Settings) Merge(other *Settings) {
+	if other == nil {
+		return
+	}
+	if other.Mask != "" {
+		s.Mask = other.Mask
+	}
+	if other.Passthrough != nil {
+		s.Passthrough = other.Passthrough
+	}
+	if other.Workdir != "" {
+		s.Workdir = other.Workdir
+	}
+	if other.Hooks != nil {
+		s.Hooks = other.Hooks
+	}
+	for name, task := range other.Tasks {
+		if s.Tasks == nil {
+			s.Tasks = make(Tasks)
+		}
+		s.Tasks[name] = task
+	}
+}
@@ -0,0 +1,101 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSettings(t *testing.T) {
+	t.Run("reserved keys are skipped in YAMLConfig", func(t *testing.T) {
+		var config model.YAMLConfig
+		gt.NoError(t, yaml.Unmarshal([]byte("mask: name\nHOST: localhost\n"), &config))
+
+		gt.Equal(t, len(config), 1)
+		gt.True(t, model.IsReservedKey("mask"))
+		gt.False(t, model.IsReservedKey("HOST"))
+	})
+
+	t.Run("reserved key holding a variable definition is an error", func(t *testing.T) {
+		for _, data := range []string{
+			"mask:\n  value: x\n",
+			"workdir:\n  file: ./dir\n",
+			"tasks: build\n",
+			"passthrough:\n  value: x\n  secret: true\n",
+			"hooks:\n  command: [echo]\n",
+		} {
+			var config model.YAMLConfig
+			err := yaml.Unmarshal([]byte(data), &config)
+			gt.Error(t, err)
+			gt.S(t, err.Error()).Contains("reserved for a setting")
+
+			var settings model.Settings
+			gt.Error(t, yaml.Unmarshal([]byte(data), &settings))
+		}
+
+		// A task may be named like a variable field
+		data := "tasks:\n  file:\n    command: [ls]\n"
+		var config model.YAMLConfig
+		gt.NoError(t, yaml.Unmarshal([]byte(data), &config))
+		var settings model.Settings
+		gt.NoError(t, yaml.Unmarshal([]byte(data), &settings))
+		gt.Equal(t, settings.Tasks["file"].Command, []string{"ls"})
+	})
+
+	t.Run("merge overrides set fields only", func(t *testing.T) {
+		settings := &model.Settings{Mask: "name"}
+		settings.Merge(&model.Settings{})
+		gt.Equal(t, settings.Mask, "name")
+
+		settings.Merge(&model.Settings{Mask: "hash"})
+		gt.Equal(t, settings.Mask, "hash")
+
+		settings.Merge(nil)
+		gt.Equal(t, settings.Mask, "hash")
+	})
+
+	t.Run("tasks are merged by name", func(t *testing.T) {
+		settings := &model.Settings{}
+		settings.Merge(&model.Settings{Tasks: model.Tasks{
+			"build": {Command: []string{"make"}},
+			"test":  {Command: []string{"make", "test"}},
+		}})
+		settings.Merge(&model.Settings{Tasks: model.Tasks{"test": {Command: []string{"go", "test"}}}})
+
+		gt.Equal(t, settings.Tasks.Names(), []string{"build", "test"})
+		gt.Equal(t, settings.Tasks["test"].Command, []string{"go", "test"})
+	})
+}
+
+func TestPassthrough(t *testing.T) {
+	t.Run("nil allows every variable", func(t *testing.T) {
+		var p *model.Passthrough
+		gt.True(t, p.Allows("ANY"))
+	})
+
+	t.Run("allow and deny lists", func(t *testing.T) {
+		p := &model.Passthrough{
+			Allow: []string{"PATH", "HOME", "LC_*", "AWS_*"},
+			Deny:  []string{"AWS_SECRET_*"},
+		}
+		gt.True(t, p.Allows("PATH"))
+		gt.True(t, p.Allows("LC_ALL"))
+		gt.True(t, p.Allows("AWS_REGION"))
+		gt.False(t, p.Allows("AWS_SECRET_ACCESS_KEY"))
+		gt.False(t, p.Allows("GITHUB_TOKEN"))
+		gt.False(t, p.Allows("path"))
+	})
+
+	t.Run("deny list only", func(t *testing.T) {
+		p := &model.Passthrough{Deny: []string{"*_TOKEN"}}
+		gt.True(t, p.Allows("PATH"))
+		gt.False(t, p.Allows("GITHUB_TOKEN"))
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		gt.Error(t, (&model.Passthrough{Allow: []string{"["}}).Validate())
+		gt.NoError(t, (&model.Passthrough{Allow: []string{"LC_*"}}).Validate())
+	})
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Task is a named command declared in the tasks section of a configuration file
+type Task struct {
+	Description string `yaml:"description,omitempty"`
+	// Command is the command and its arguments
+	Command []string `yaml:"command"`
+	// Env sets extra variables for the task, overriding the loaded ones
+	Env map[string]string `yaml:"env,omitempty"`
+	// Profile is used unless a profile is given on the command line
+	Profile string `yaml:"profile,omitempty"`
+	// Template expands the command arguments as templates, like -t
+	Template bool `yaml:"template,omitempty"`
+	// Depends lists tasks that run, in order, before this one
+	Depends []string `yaml:"depends,omitempty"`
+}
+
+// Tasks are the tasks of a configuration by name
+type Tasks map[string]*Task
+
+// Validate checks that every task has a command and that dependencies exist
+// and are not circular
+func (t Tasks) Validate() error {
+	for _, name := range t.Names() {
+		if len(t[name].Command) == 0 {
+			return goerr.New("task has no command", goerr.V("task", name))
+		}
+		if _, err := t.Order(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names returns the task names in sorted order
+func (t Tasks) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order returns the tasks to run for name: its dependencies, each once and
+// before the tasks depending on it, followed by name itself.
+func (t Tasks) Order(name string) ([]string, error) {
+	var order []string
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if visiting[name] {
+			return goerr.New("circular task dependency", goerr.V("task", name))
+		}
+		task, ok := t[name]
+		if !ok {
+			return goerr.New("task not found", goerr.V("task", name))
+		}
+
+		visiting[name] = true
+		for _, dep := range task.Depends {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		delete(visiting, name)
+
+		done[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+func TestTasks(t *testing.T) {
+	tasks := model.Tasks{
+		"build":   {Command: []string{"make"}},
+		"migrate": {Command: []string{"migrate"}, Depends: []string{"build"}},
+		"seed":    {Command: []string{"seed"}, Depends: []string{"build"}},
+		"test":    {Command: []string{"go", "test"}, Depends: []string{"migrate", "seed"}},
+	}
+
+	t.Run("dependencies run once and first", func(t *testing.T) {
+		gt.NoError(t, tasks.Validate())
+		gt.Equal(t, gt.R1(tasks.Order("test")).NoError(t), []string{"build", "migrate", "seed", "test"})
+		gt.Equal(t, gt.R1(tasks.Order("build")).NoError(t), []string{"build"})
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		_, err := tasks.Order("deploy")
+		gt.Error(t, err)
+	})
+
+	t.Run("invalid tasks", func(t *testing.T) {
+		gt.Error(t, model.Tasks{"a": {}}.Validate())
+		gt.Error(t, model.Tasks{"a": {Command: []string{"true"}, Depends: []string{"missing"}}}.Validate())
+		gt.Error(t, model.Tasks{
+			"a": {Command: []string{"true"}, Depends: []string{"b"}},
+			"b": {Command: []string{"true"}, Depends: []string{"a"}},
+		}.Validate())
+	})
+}
@@ -0,0 +1,78 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// handler is a slog.Handler that masks secret values in the message and
+// attributes before passing the record to the next handler.
+type handler struct {
+	next    slog.Handler
+	secrets *Secrets
+}
+
+// NewHandler wraps next so that every registered secret is masked in log
+// output. Attributes bound with WithAttrs are scrubbed with the secrets known
+// at that time.
+func NewHandler(next slog.Handler, secrets *Secrets) slog.Handler {
+	return &handler{next: next, secrets: secrets}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, h.secrets.Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(h.scrubAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, scrubbed)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = h.scrubAttr(a)
+	}
+	return &handler{next: h.next.WithAttrs(scrubbed), secrets: h.secrets}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), secrets: h.secrets}
+}
+
+func (h *handler) scrubAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.secrets.Scrub(v.String()))
+
+	case slog.KindGroup:
+		group := v.Group()
+		scrubbed := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			scrubbed[i] = h.scrubAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(scrubbed...)}
+
+	case slog.KindAny:
+		// Values such as []string or errors are rendered as text only when
+		// they contain a secret; otherwise the original value is kept.
+		var text string
+		if err, ok := v.Any().(error); ok {
+			text = err.Error()
+		} else {
+			text = fmt.Sprint(v.Any())
+		}
+		if scrubbed := h.secrets.Scrub(text); scrubbed != text {
+			return slog.String(a.Key, scrubbed)
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,68 @@
+package redact_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+func TestHandler(t *testing.T) {
+	newLogger := func(secrets *redact.Secrets) (*slog.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+		return slog.New(redact.NewHandler(h, secrets)), &buf
+	}
+
+	t.Run("scrub message and string attributes", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t")
+		logger, buf := newLogger(secrets)
+
+		logger.Debug("expanded s3cr3t", "result", "pass=s3cr3t", "count", 3)
+
+		gt.S(t, buf.String()).NotContains("s3cr3t")
+		gt.S(t, buf.String()).Contains(`"msg":"expanded *****"`)
+		gt.S(t, buf.String()).Contains(`"result":"pass=*****"`)
+		gt.S(t, buf.String()).Contains(`"count":3`)
+	})
+
+	t.Run("scrub slices, groups, errors and bound attributes", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t")
+		logger, buf := newLogger(secrets)
+
+		logger.With("bound", "s3cr3t").WithGroup("g").Info("executing command",
+			"args", []string{"-p", "s3cr3t"},
+			slog.Group("nested", "value", "s3cr3t"),
+			"error", errors.New("failed: s3cr3t"),
+			"goerr", goerr.New("command failed", goerr.V("command", []string{"echo", "s3cr3t"})),
+		)
+
+		gt.S(t, buf.String()).NotContains("s3cr3t")
+		gt.S(t, buf.String()).Contains("*****")
+	})
+
+	t.Run("secrets added after logger creation are scrubbed", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		logger, buf := newLogger(secrets)
+
+		secrets.Add("late-secret")
+		logger.Info("value", "v", "late-secret")
+
+		gt.S(t, buf.String()).NotContains("late-secret")
+	})
+
+	t.Run("non-secret values keep their type", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t")
+		logger, buf := newLogger(secrets)
+
+		logger.Info("ok", "args", []string{"a", "b"})
+		gt.S(t, buf.String()).Contains(`"args":["a","b"]`)
+	})
+}
@@ -0,0 +1,84 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// PolicyKind selects how a secret value is replaced
+type PolicyKind int
+
+const (
+	// PolicyFixed replaces every secret with Mask
+	PolicyFixed PolicyKind = iota
+	// PolicyName replaces a secret with [REDACTED:NAME]
+	PolicyName
+	// PolicyPartial reveals the last characters of long secrets
+	PolicyPartial
+	// PolicyHash replaces a secret with a short SHA-256 fingerprint
+	PolicyHash
+)
+
+// DefaultPartialReveal is the number of characters revealed by "partial"
+const DefaultPartialReveal = 4
+
+// Policy decides the text that replaces a secret value. None of the policies
+// reveal the length of the value. The zero value is the fixed mask.
+type Policy struct {
+	Kind PolicyKind
+	// Reveal is the number of trailing characters shown by PolicyPartial
+	Reveal int
+}
+
+// ParsePolicy parses a policy name: "fixed", "name", "partial", "partial:N"
+// or "hash". An empty string is the fixed mask.
+func ParsePolicy(s string) (Policy, error) {
+	name, arg, hasArg := strings.Cut(s, ":")
+	if hasArg && name != "partial" {
+		return Policy{}, goerr.New("mask policy takes no argument", goerr.V("policy", s))
+	}
+
+	switch name {
+	case "", "fixed":
+		return Policy{Kind: PolicyFixed}, nil
+	case "name":
+		return Policy{Kind: PolicyName}, nil
+	case "hash":
+		return Policy{Kind: PolicyHash}, nil
+	case "partial":
+		reveal := DefaultPartialReveal
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return Policy{}, goerr.New("invalid number of revealed characters", goerr.V("policy", s))
+			}
+			reveal = n
+		}
+		return Policy{Kind: PolicyPartial, Reveal: reveal}, nil
+	default:
+		return Policy{}, goerr.New("unknown mask policy (expected fixed, name, partial[:N] or hash)", goerr.V("policy", s))
+	}
+}
+
+// Mask returns the replacement for the value of the secret variable name
+func (p Policy) Mask(name, value string) string {
+	switch p.Kind {
+	case PolicyName:
+		return "[REDACTED:" + name + "]"
+	case PolicyPartial:
+		// Reveal only when at least three quarters of the value stays hidden
+		if p.Reveal > 0 && len(value) >= p.Reveal*4 {
+			return Mask + value[len(value)-p.Reveal:]
+		}
+		return Mask
+	case PolicyHash:
+		sum := sha256.Sum256([]byte(value))
+		return "[sha256:" + hex.EncodeToString(sum[:4]) + "]"
+	default:
+		return Mask
+	}
+}
@@ -0,0 +1,66 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    redact.Policy
+		wantErr bool
+	}{
+		{input: "", want: redact.Policy{Kind: redact.PolicyFixed}},
+		{input: "fixed", want: redact.Policy{Kind: redact.PolicyFixed}},
+		{input: "name", want: redact.Policy{Kind: redact.PolicyName}},
+		{input: "hash", want: redact.Policy{Kind: redact.PolicyHash}},
+		{input: "partial", want: redact.Policy{Kind: redact.PolicyPartial, Reveal: redact.DefaultPartialReveal}},
+		{input: "partial:6", want: redact.Policy{Kind: redact.PolicyPartial, Reveal: 6}},
+		{input: "partial:0", wantErr: true},
+		{input: "partial:x", wantErr: true},
+		{input: "name:3", wantErr: true},
+		{input: "stars", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := redact.ParsePolicy(tt.input)
+			if tt.wantErr {
+				gt.Error(t, err)
+				return
+			}
+			gt.NoError(t, err)
+			gt.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestPolicyMask(t *testing.T) {
+	t.Run("fixed mask does not depend on the value", func(t *testing.T) {
+		var p redact.Policy
+		gt.Equal(t, p.Mask("A", "x"), redact.Mask)
+		gt.Equal(t, p.Mask("A", "a-much-longer-secret-value"), redact.Mask)
+	})
+
+	t.Run("name mask", func(t *testing.T) {
+		p := redact.Policy{Kind: redact.PolicyName}
+		gt.Equal(t, p.Mask("DB_PASSWORD", "hunter2"), "[REDACTED:DB_PASSWORD]")
+	})
+
+	t.Run("partial mask reveals the tail of long values only", func(t *testing.T) {
+		p := redact.Policy{Kind: redact.PolicyPartial, Reveal: 4}
+		gt.Equal(t, p.Mask("TOKEN", "ghp_abcdefghijklmnop1234"), "*****1234")
+		gt.Equal(t, p.Mask("PASS", "hunter2"), redact.Mask)
+	})
+
+	t.Run("hash mask is a stable short fingerprint", func(t *testing.T) {
+		p := redact.Policy{Kind: redact.PolicyHash}
+		// sha256("hunter2") = f52fbd32...
+		gt.Equal(t, p.Mask("A", "hunter2"), "[sha256:f52fbd32]")
+		gt.Equal(t, p.Mask("B", "hunter2"), p.Mask("A", "hunter2"))
+		gt.NotEqual(t, p.Mask("A", "hunter3"), p.Mask("A", "hunter2"))
+	})
+}
@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secret values in scrubbed logs and error messages
+const Mask = "*****"
+
+// Secrets is the set of secret values known to the current zenv run. Values
+// are added once environment variables are resolved, and the set is shared by
+// the logger and error reporting so that neither prints a raw secret.
+type Secrets struct {
+	mu       sync.RWMutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}
+
+// NewSecrets creates an empty secret set
+func NewSecrets() *Secrets {
+	return &Secrets{values: make(map[string]bool)}
+}
+
+// Add registers secret values. Empty values are ignored.
+func (s *Secrets) Add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, v := range values {
+		if v != "" && !s.values[v] {
+			s.values[v] = true
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// Longest first, so a secret containing another is masked as a whole
+	sorted := make([]string, 0, len(s.values))
+	for v := range s.values {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	pairs := make([]string, 0, len(sorted)*2)
+	for _, v := range sorted {
+		pairs = append(pairs, v, Mask)
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+}
+
+// Scrub returns str with every registered secret value masked
+func (s *Secrets) Scrub(str string) string {
+	if s == nil {
+		return str
+	}
+	s.mu.RLock()
+	replacer := s.replacer
+	s.mu.RUnlock()
+
+	if replacer == nil {
+		return str
+	}
+	return replacer.Replace(str)
+}
+
+// ScrubError returns an error whose message has secret values masked. The
+// original error is still reachable through errors.Is/As, so exit codes and
+// error types are preserved.
+func (s *Secrets) ScrubError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	scrubbed := s.Scrub(msg)
+	if scrubbed == msg {
+		return err
+	}
+	return &scrubbedError{err: err, msg: scrubbed}
+}
+
+type scrubbedError struct {
+	err error
+	msg string
+}
+
+func (e *scrubbedError) Error() string {
+	return e.msg
+}
+
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
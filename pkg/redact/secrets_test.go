@@ -0,0 +1,54 @@
+package redact_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Run("scrub registered values", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t", "", "token-abc")
+
+		gt.Equal(t, secrets.Scrub("pass=s3cr3t token=token-abc"), "pass=***** token=*****")
+	})
+
+	t.Run("longer secret is masked as a whole", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("abc", "abcdef")
+
+		gt.Equal(t, secrets.Scrub("x=abcdef"), "x=*****")
+	})
+
+	t.Run("nil and empty set pass through", func(t *testing.T) {
+		var nilSecrets *redact.Secrets
+		gt.Equal(t, nilSecrets.Scrub("plain"), "plain")
+		gt.Equal(t, redact.NewSecrets().Scrub("plain"), "plain")
+	})
+
+	t.Run("scrub error keeps the original error reachable", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t")
+
+		execErr := model.NewExecutorError(errors.New("failed with s3cr3t"), 42)
+		err := secrets.ScrubError(execErr)
+
+		gt.S(t, err.Error()).NotContains("s3cr3t")
+		gt.S(t, err.Error()).Contains("*****")
+		gt.True(t, model.IsExecutorError(err))
+		gt.Equal(t, model.GetExitCode(err), 42)
+	})
+
+	t.Run("error without secret is returned as is", func(t *testing.T) {
+		secrets := redact.NewSecrets()
+		secrets.Add("s3cr3t")
+
+		original := errors.New("nothing to hide")
+		gt.Equal(t, secrets.ScrubError(original), original)
+		gt.NoError(t, secrets.ScrubError(nil))
+	})
+}
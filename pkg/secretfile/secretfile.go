@@ -0,0 +1,73 @@
+package secretfile
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Dir is a private temporary directory holding files materialized from secret values
+type Dir struct {
+	path string
+}
+
+// NewDir creates a new private (0700) directory for secret files.
+// $XDG_RUNTIME_DIR is preferred because it is usually a per-user tmpfs;
+// the system temporary directory is used otherwise.
+func NewDir() (*Dir, error) {
+	base := os.TempDir()
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		if info, err := os.Stat(runtimeDir); err == nil && info.IsDir() {
+			base = runtimeDir
+		}
+	}
+
+	path, err := os.MkdirTemp(base, "zenv-")
+	if err != nil {
+		return nil, goerr.Wrap(err, "failed to create secret file directory", goerr.V("base", base))
+	}
+	if err := os.Chmod(path, 0o700); err != nil {
+		_ = os.RemoveAll(path)
+		return nil, goerr.Wrap(err, "failed to set secret file directory permission", goerr.V("path", path))
+	}
+
+	return &Dir{path: path}, nil
+}
+
+// Path returns the directory path
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Write creates a file named after name containing value and returns its path.
+// The file is created exclusively, so writing the same name twice is an error.
+func (d *Dir) Write(name, value string, mode os.FileMode) (string, error) {
+	path := filepath.Join(d.path, filepath.Base(name))
+
+	// Create with owner write permission first so that read-only modes (e.g. 0400) can be written
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 - path is inside the private directory
+	if err != nil {
+		return "", goerr.Wrap(err, "failed to create secret file", goerr.V("path", path))
+	}
+	if _, err := f.WriteString(value); err != nil {
+		_ = f.Close()
+		return "", goerr.Wrap(err, "failed to write secret file", goerr.V("path", path))
+	}
+	if err := f.Close(); err != nil {
+		return "", goerr.Wrap(err, "failed to close secret file", goerr.V("path", path))
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return "", goerr.Wrap(err, "failed to set secret file permission", goerr.V("path", path))
+	}
+
+	return path, nil
+}
+
+// Remove deletes the directory and all files in it
+func (d *Dir) Remove() error {
+	if err := os.RemoveAll(d.path); err != nil {
+		return goerr.Wrap(err, "failed to remove secret file directory", goerr.V("path", d.path))
+	}
+	return nil
+}
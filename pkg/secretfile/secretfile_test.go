@@ -0,0 +1,61 @@
+package secretfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/secretfile"
+)
+
+func TestDir(t *testing.T) {
+	t.Run("prefers XDG_RUNTIME_DIR", func(t *testing.T) {
+		runtimeDir := t.TempDir()
+		t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+		dir := gt.R1(secretfile.NewDir()).NoError(t)
+		defer func() { _ = dir.Remove() }()
+
+		gt.Equal(t, filepath.Dir(dir.Path()), runtimeDir)
+		info := gt.R1(os.Stat(dir.Path())).NoError(t)
+		gt.Equal(t, info.Mode().Perm(), os.FileMode(0o700))
+	})
+
+	t.Run("falls back to temp dir when XDG_RUNTIME_DIR is missing", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", filepath.Join(t.TempDir(), "not-exist"))
+
+		dir := gt.R1(secretfile.NewDir()).NoError(t)
+		defer func() { _ = dir.Remove() }()
+
+		gt.Equal(t, filepath.Dir(dir.Path()), filepath.Clean(os.TempDir()))
+	})
+
+	t.Run("writes file with requested mode and removes it", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+		dir := gt.R1(secretfile.NewDir()).NoError(t)
+		path := gt.R1(dir.Write("DB_PASSWORD", "s3cr3t", 0o400)).NoError(t)
+
+		gt.Equal(t, filepath.Base(path), "DB_PASSWORD")
+		content := gt.R1(os.ReadFile(path)).NoError(t)
+		gt.Equal(t, string(content), "s3cr3t")
+		info := gt.R1(os.Stat(path)).NoError(t)
+		gt.Equal(t, info.Mode().Perm(), os.FileMode(0o400))
+
+		gt.NoError(t, dir.Remove())
+		_, err := os.Stat(dir.Path())
+		gt.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("rejects duplicated name", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+		dir := gt.R1(secretfile.NewDir()).NoError(t)
+		defer func() { _ = dir.Remove() }()
+
+		gt.R1(dir.Write("TOKEN", "a", 0o600)).NoError(t)
+		_, err := dir.Write("TOKEN", "b", 0o600)
+		gt.Error(t, err)
+	})
+}
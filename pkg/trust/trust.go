@@ -0,0 +1,164 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Store records the configuration files the user allowed to run commands,
+// with a hash of their content so that any change requires a new approval.
+// It is kept in a JSON file.
+type Store struct {
+	path string
+}
+
+type storeFile struct {
+	// Allowed maps the absolute path of a file to the SHA-256 of its content
+	Allowed map[string]string `json:"allowed"`
+}
+
+// DefaultPath returns $XDG_DATA_HOME/zenv/trust.json, or
+// ~/.local/share/zenv/trust.json when XDG_DATA_HOME is not set.
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", goerr.Wrap(err, "failed to find home directory for trust store")
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "zenv", "trust.json"), nil
+}
+
+// NewStore returns the store kept at path. The file is created on the first
+// approval.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Allow records the current content of file as trusted
+func (s *Store) Allow(file string) error {
+	key, hash, err := fileHash(file)
+	if err != nil {
+		return err
+	}
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	data.Allowed[key] = hash
+	return s.save(data)
+}
+
+// Deny revokes the approval of file. It reports whether file was allowed.
+func (s *Store) Deny(file string) (bool, error) {
+	key := canonicalPath(file)
+	data, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := data.Allowed[key]; !ok {
+		return false, nil
+	}
+	delete(data.Allowed, key)
+	return true, s.save(data)
+}
+
+// Check returns an error unless file was allowed with its current content
+func (s *Store) Check(file string) error {
+	key, hash, err := fileHash(file)
+	if err != nil {
+		return err
+	}
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	allowed, ok := data.Allowed[key]
+	switch {
+	case !ok:
+		return goerr.New("configuration file runs commands and is not allowed; review it and run `zenv allow`, or use --trust",
+			goerr.V("path", key))
+	case allowed != hash:
+		return goerr.New("configuration file changed since it was allowed; review it and run `zenv allow` again, or use --trust",
+			goerr.V("path", key))
+	}
+	return nil
+}
+
+func (s *Store) load() (*storeFile, error) {
+	data := &storeFile{Allowed: make(map[string]string)}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, goerr.Wrap(err, "failed to read trust store", goerr.V("path", s.path))
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, goerr.Wrap(err, "failed to parse trust store", goerr.V("path", s.path))
+	}
+	if data.Allowed == nil {
+		data.Allowed = make(map[string]string)
+	}
+	return data, nil
+}
+
+// save replaces the store file, so that readers never see a partial file
+func (s *Store) save(data *storeFile) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return goerr.Wrap(err, "failed to encode trust store")
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return goerr.Wrap(err, "failed to create trust store directory", goerr.V("dir", dir))
+	}
+	tmp, err := os.CreateTemp(dir, ".trust-*.json")
+	if err != nil {
+		return goerr.Wrap(err, "failed to write trust store", goerr.V("path", s.path))
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		_ = tmp.Close()
+		return goerr.Wrap(err, "failed to write trust store", goerr.V("path", s.path))
+	}
+	if err := tmp.Close(); err != nil {
+		return goerr.Wrap(err, "failed to write trust store", goerr.V("path", s.path))
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return goerr.Wrap(err, "failed to write trust store", goerr.V("path", s.path))
+	}
+	return nil
+}
+
+// canonicalPath returns the absolute path of file with symbolic links
+// resolved, so that a file is recorded once however it is reached
+func canonicalPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(file); err == nil {
+		file = resolved
+	}
+	return file
+}
+
+func fileHash(file string) (string, string, error) {
+	key := canonicalPath(file)
+	content, err := os.ReadFile(key) // #nosec G304 - file path is user provided and expected
+	if err != nil {
+		return "", "", goerr.Wrap(err, "failed to read configuration file", goerr.V("path", file))
+	}
+	sum := sha256.Sum256(content)
+	return key, hex.EncodeToString(sum[:]), nil
+}
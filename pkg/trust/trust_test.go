@@ -0,0 +1,49 @@
+package trust_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/trust"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".env.yaml")
+	gt.NoError(t, os.WriteFile(config, []byte("TOKEN:\n  command: [\"get-token\"]\n"), 0o600))
+	storePath := filepath.Join(dir, "data", "zenv", "trust.json")
+	store := trust.NewStore(storePath)
+
+	gt.Error(t, store.Check(config))
+
+	gt.NoError(t, store.Allow(config))
+	gt.NoError(t, store.Check(config))
+	info := gt.R1(os.Stat(storePath)).NoError(t)
+	gt.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	// A symbolic link reaches the same approval
+	link := filepath.Join(dir, "link.yaml")
+	gt.NoError(t, os.Symlink(config, link))
+	gt.NoError(t, store.Check(link))
+
+	// Any change requires a new approval
+	gt.NoError(t, os.WriteFile(config, []byte("TOKEN:\n  command: [\"steal-token\"]\n"), 0o600))
+	gt.Error(t, store.Check(config))
+	gt.NoError(t, store.Allow(config))
+	gt.NoError(t, store.Check(config))
+
+	gt.True(t, gt.R1(store.Deny(config)).NoError(t))
+	gt.Error(t, store.Check(config))
+	gt.False(t, gt.R1(store.Deny(config)).NoError(t))
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	gt.Equal(t, gt.R1(trust.DefaultPath()).NoError(t), "/data/zenv/trust.json")
+
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	gt.Equal(t, gt.R1(trust.DefaultPath()).NoError(t), "/home/me/.local/share/zenv/trust.json")
+}
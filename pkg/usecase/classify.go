@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// DefaultSecretNamePatterns are the variable name globs treated as secret by default
+var DefaultSecretNamePatterns = []string{
+	"*_TOKEN",
+	"*_PASSWORD",
+	"*_PASSWD",
+	"*_SECRET",
+	"*_SECRET_KEY",
+	"*_API_KEY",
+	"*_ACCESS_KEY",
+	"*_PRIVATE_KEY",
+	"*_CREDENTIALS",
+}
+
+const (
+	// DefaultSecretEntropy is the Shannon entropy (bits per character) above
+	// which a token-like value is treated as secret
+	DefaultSecretEntropy = 4.0
+
+	// minEntropyValueLen is the shortest value considered by the entropy rule.
+	// Short values can't carry enough information to be judged.
+	minEntropyValueLen = 20
+)
+
+// SecretClassifier marks variables from any source as secret based on their
+// name and value, so that values such as GITHUB_TOKEN from the system
+// environment or a .env file are redacted without being declared secret.
+type SecretClassifier struct {
+	// NamePatterns are case-insensitive globs (path.Match syntax) matched against variable names
+	NamePatterns []string
+	// EntropyThreshold is the minimum entropy of a token-like value; 0 disables the rule
+	EntropyThreshold float64
+}
+
+// NewSecretClassifier creates a classifier with the default rules
+func NewSecretClassifier() *SecretClassifier {
+	return &SecretClassifier{
+		NamePatterns:     append([]string{}, DefaultSecretNamePatterns...),
+		EntropyThreshold: DefaultSecretEntropy,
+	}
+}
+
+// Classify sets Secret and SecretReason on variables matching a rule.
+// Variables already marked secret are left unchanged.
+func (c *SecretClassifier) Classify(envVars []*model.EnvVar) {
+	for _, envVar := range envVars {
+		if envVar.Secret || envVar.Value == "" {
+			continue
+		}
+		if reason := c.reason(envVar); reason != "" {
+			envVar.Secret = true
+			envVar.SecretReason = reason
+		}
+	}
+}
+
+func (c *SecretClassifier) reason(envVar *model.EnvVar) string {
+	name := strings.ToUpper(envVar.Name)
+	for _, pattern := range c.NamePatterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), name); ok {
+			return "name matches " + pattern
+		}
+	}
+
+	if c.EntropyThreshold > 0 && isTokenLike(envVar.Value) {
+		if entropy := shannonEntropy(envVar.Value); entropy >= c.EntropyThreshold {
+			return fmt.Sprintf("high entropy value (%.1f bits/char)", entropy)
+		}
+	}
+
+	return ""
+}
+
+// isTokenLike reports whether value looks like an API key or random token:
+// long enough and made only of characters used by common token encodings.
+// Paths, URLs and lists (which contain ':', ';', spaces, etc.) are excluded.
+func isTokenLike(value string) bool {
+	if len(value) < minEntropyValueLen || strings.HasPrefix(value, "/") {
+		return false
+	}
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("+/=_-.~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
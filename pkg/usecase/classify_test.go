@@ -0,0 +1,67 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestSecretClassifier(t *testing.T) {
+	classify := func(c *usecase.SecretClassifier, name, value string) *model.EnvVar {
+		envVar := &model.EnvVar{Name: name, Value: value, Source: model.SourceSystem}
+		c.Classify([]*model.EnvVar{envVar})
+		return envVar
+	}
+
+	t.Run("name patterns", func(t *testing.T) {
+		c := usecase.NewSecretClassifier()
+
+		v := classify(c, "GITHUB_TOKEN", "ghp_x")
+		gt.True(t, v.Secret)
+		gt.Equal(t, v.SecretReason, "name matches *_TOKEN")
+
+		gt.True(t, classify(c, "db_password", "pw").Secret)
+		gt.True(t, classify(c, "AWS_SECRET_ACCESS_KEY", "x").Secret)
+		gt.False(t, classify(c, "TOKEN_COUNT", "3").Secret)
+		gt.False(t, classify(c, "HOME", "/home/user").Secret)
+	})
+
+	t.Run("custom name pattern", func(t *testing.T) {
+		c := usecase.NewSecretClassifier()
+		c.NamePatterns = append(c.NamePatterns, "*_DSN")
+
+		gt.True(t, classify(c, "SENTRY_DSN", "https://example").Secret)
+	})
+
+	t.Run("high entropy token-like value", func(t *testing.T) {
+		c := usecase.NewSecretClassifier()
+
+		v := classify(c, "SOME_VALUE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+		gt.True(t, v.Secret)
+		gt.S(t, v.SecretReason).HasPrefix("high entropy value")
+
+		// Not token-like or low entropy
+		gt.False(t, classify(c, "LIST", "alpha beta gamma delta epsilon zeta").Secret)
+		gt.False(t, classify(c, "GIT_SHA", "aaaaaaaaaabbbbbbbbbbccccccccccdddddddddd").Secret)
+		gt.False(t, classify(c, "GOPATH", "/home/user/go/pkg/mod/github.com/abcdefghijk").Secret)
+		gt.False(t, classify(c, "SHORT", "Xy9$").Secret)
+	})
+
+	t.Run("entropy rule disabled", func(t *testing.T) {
+		c := usecase.NewSecretClassifier()
+		c.EntropyThreshold = 0
+
+		gt.False(t, classify(c, "SOME_VALUE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY").Secret)
+	})
+
+	t.Run("declared secrets keep no reason", func(t *testing.T) {
+		c := usecase.NewSecretClassifier()
+		envVar := &model.EnvVar{Name: "API_TOKEN", Value: "x", Source: model.SourceYAML, Secret: true}
+		c.Classify([]*model.EnvVar{envVar})
+
+		gt.True(t, envVar.Secret)
+		gt.Equal(t, envVar.SecretReason, "")
+	})
+}
@@ -0,0 +1,3 @@
+package usecase
+
+var EnvVarChanges = envVarChanges
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+var exportName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// runBeforeHooks runs the before hooks with envVars and returns envVars with
+// the variables exported by the hooks added
+func (uc *UseCase) runBeforeHooks(ctx context.Context, envVars []*model.EnvVar) ([]*model.EnvVar, error) {
+	logger := ctxlog.From(ctx)
+
+	for i, hook := range uc.Hooks.Before {
+		logger.Debug("running before hook", "index", i, "command", hook.Command)
+		exported, err := uc.runHook(ctx, hook, envVars)
+		if err != nil {
+			if uc.Hooks.IsFailFast() {
+				return nil, goerr.Wrap(err, "before hook failed", goerr.V("index", i), goerr.V("command", hook.Command))
+			}
+			logger.Warn("before hook failed", "index", i, "command", hook.Command, "error", err)
+			continue
+		}
+		if len(exported) > 0 {
+			logger.Debug("before hook exported variables", "index", i, "count", len(exported))
+			envVars = mergeEnvVars(append(slices.Clone(envVars), exported...))
+		}
+	}
+	return envVars, nil
+}
+
+// runAfterHooks runs the after hooks with envVars. They run to completion even
+// if ctx is cancelled, since they usually clean up.
+func (uc *UseCase) runAfterHooks(ctx context.Context, envVars []*model.EnvVar) error {
+	logger := ctxlog.From(ctx)
+	ctx = context.WithoutCancel(ctx)
+
+	for i, hook := range uc.Hooks.After {
+		logger.Debug("running after hook", "index", i, "command", hook.Command)
+		if _, err := uc.runHook(ctx, hook, envVars); err != nil {
+			if uc.Hooks.IsFailFast() {
+				return goerr.Wrap(err, "after hook failed", goerr.V("index", i), goerr.V("command", hook.Command))
+			}
+			logger.Warn("after hook failed", "index", i, "command", hook.Command, "error", err)
+		}
+	}
+	return nil
+}
+
+// runHook runs a hook with its output sent to stderr, keeping the command's
+// stdout clean. The stdout of an exporting hook is read as KEY=value lines.
+func (uc *UseCase) runHook(ctx context.Context, hook *model.Hook, envVars []*model.EnvVar) ([]*model.EnvVar, error) {
+	ctx = executor.ContextWithOutput(ctx, os.Stderr, os.Stderr)
+	var stdout bytes.Buffer
+	if hook.Export {
+		ctx = executor.ContextWithCapture(ctx, &stdout)
+	}
+
+	exec := uc.HookExecutor
+	if exec == nil {
+		exec = uc.Executor
+	}
+	if err := exec(ctx, hook.Command[0], hook.Command[1:], envVars); err != nil {
+		return nil, err
+	}
+	if !hook.Export {
+		return nil, nil
+	}
+
+	exported, err := parseHookExports(stdout.Bytes(), hook.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if uc.Classifier != nil {
+		uc.Classifier.Classify(exported)
+	}
+	if uc.Secrets != nil {
+		for _, envVar := range exported {
+			if envVar.Secret {
+				uc.Secrets.Add(envVar.Value)
+			}
+		}
+	}
+	return exported, nil
+}
+
+// parseHookExports reads KEY=value lines, optionally prefixed with "export".
+// Empty lines and # comments are skipped and quotes around values removed.
+func parseHookExports(output []byte, secret bool) ([]*model.EnvVar, error) {
+	var envVars []*model.EnvVar
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		// The line is not included in errors, as it may hold a secret
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found || !exportName.MatchString(name) {
+			return nil, goerr.New("invalid line in hook output, expected KEY=value", goerr.V("line", lineNumber))
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 {
+			if (value[0] == '"' && value[len(value)-1] == '"') ||
+				(value[0] == '\'' && value[len(value)-1] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+		}
+
+		envVars = append(envVars, &model.EnvVar{
+			Name:   name,
+			Value:  value,
+			Source: model.SourceHook,
+			Secret: secret,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, goerr.Wrap(err, "failed to read hook output")
+	}
+	return envVars, nil
+}
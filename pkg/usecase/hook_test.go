@@ -0,0 +1,123 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestHooks(t *testing.T) {
+	newUseCase := func(t *testing.T) (*usecase.UseCase, string) {
+		return usecase.NewUseCase(nil, executor.NewDefaultExecutor()), filepath.Join(t.TempDir(), "log")
+	}
+	record := func(logPath, text string) []string {
+		return []string{"sh", "-c", `echo "` + text + `" >> ` + logPath}
+	}
+	readLog := func(t *testing.T, logPath string) string {
+		data, err := os.ReadFile(logPath)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		gt.NoError(t, err)
+		return string(data)
+	}
+	failFast := false
+
+	t.Run("before hooks export variables to later hooks and the command", func(t *testing.T) {
+		uc, logPath := newUseCase(t)
+		uc.Hooks = &model.Hooks{
+			Before: []*model.Hook{
+				{Command: []string{"sh", "-c", `echo "export TOKEN='t0ken'"; echo; echo '# comment'`}, Export: true, Secret: true},
+				{Command: []string{"sh", "-c", `echo "URL=https://$TOKEN@example.com"`}, Export: true},
+				{Command: record(logPath, "before")},
+			},
+			After: []*model.Hook{{Command: record(logPath, "after $TOKEN")}},
+		}
+
+		err := uc.Run(context.Background(), append([]string{"TOKEN=old"}, record(logPath, "command $TOKEN $URL")...))
+		gt.NoError(t, err)
+		gt.Equal(t, readLog(t, logPath), "before\ncommand t0ken https://t0ken@example.com\nafter t0ken\n")
+	})
+
+	t.Run("failing before hook stops the run", func(t *testing.T) {
+		uc, logPath := newUseCase(t)
+		uc.Hooks = &model.Hooks{
+			Before: []*model.Hook{{Command: []string{"sh", "-c", "exit 5"}}, {Command: record(logPath, "before")}},
+			After:  []*model.Hook{{Command: record(logPath, "after")}},
+		}
+
+		err := uc.Run(context.Background(), record(logPath, "command"))
+		gt.Error(t, err)
+		gt.Equal(t, model.GetExitCode(err), 5)
+		gt.Equal(t, readLog(t, logPath), "")
+
+		uc.Hooks.AlwaysAfter = true
+		gt.Error(t, uc.Run(context.Background(), record(logPath, "command")))
+		gt.Equal(t, readLog(t, logPath), "after\n")
+	})
+
+	t.Run("without fail fast hook failures are only reported", func(t *testing.T) {
+		uc, logPath := newUseCase(t)
+		uc.Hooks = &model.Hooks{
+			Before:   []*model.Hook{{Command: []string{"false"}}, {Command: record(logPath, "before")}},
+			After:    []*model.Hook{{Command: []string{"false"}}, {Command: record(logPath, "after")}},
+			FailFast: &failFast,
+		}
+
+		gt.NoError(t, uc.Run(context.Background(), record(logPath, "command")))
+		gt.Equal(t, readLog(t, logPath), "before\ncommand\nafter\n")
+	})
+
+	t.Run("after hooks run on command failure only when always_after", func(t *testing.T) {
+		uc, logPath := newUseCase(t)
+		uc.Hooks = &model.Hooks{After: []*model.Hook{{Command: record(logPath, "after")}}}
+
+		err := uc.Run(context.Background(), []string{"sh", "-c", "exit 3"})
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.Equal(t, readLog(t, logPath), "")
+
+		uc.Hooks.AlwaysAfter = true
+		err = uc.Run(context.Background(), []string{"sh", "-c", "exit 3"})
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.Equal(t, readLog(t, logPath), "after\n")
+	})
+
+	t.Run("invalid export output", func(t *testing.T) {
+		uc, _ := newUseCase(t)
+		uc.Hooks = &model.Hooks{
+			Before: []*model.Hook{{Command: []string{"echo", "not an assignment"}, Export: true}},
+		}
+		gt.Error(t, uc.Run(context.Background(), []string{"true"}))
+	})
+}
+
+// TestAfterHooksExecHelper runs a command with hooks for TestAfterHooksExec
+// with exec mode enabled, since exec replaces the process.
+func TestAfterHooksExecHelper(t *testing.T) {
+	if os.Getenv("ZENV_TEST_HOOK_HELPER") == "" {
+		t.Skip("helper for TestAfterHooksExec")
+	}
+
+	uc := usecase.NewUseCase(nil, executor.NewDefaultExecutor(executor.WithExecMode(executor.ExecAuto)))
+	uc.Hooks = &model.Hooks{After: []*model.Hook{{Command: []string{"echo", "AFTER"}}}}
+	gt.NoError(t, uc.Run(context.Background(), []string{"echo", "MAIN"}))
+	os.Exit(0)
+}
+
+func TestAfterHooksExec(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestAfterHooksExecHelper$")
+	cmd.Env = append(os.Environ(), "ZENV_TEST_HOOK_HELPER=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out := gt.R1(cmd.Output()).NoError(t)
+	gt.Equal(t, string(out), "MAIN\n")
+	gt.S(t, stderr.String()).Contains("AFTER\n")
+}
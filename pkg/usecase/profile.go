@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// ProfileRunner runs a command once for each of several profiles
+type ProfileRunner struct {
+	Profiles []string
+	// Parallel runs the command for all profiles at once instead of one
+	// profile after another
+	Parallel bool
+	// NewUseCase returns the use case that runs a command with the
+	// environment of profile
+	NewUseCase func(profile string) *UseCase
+	// Stdout and Stderr receive the output of the command, each line prefixed
+	// with the profile. Nil means os.Stdout and os.Stderr.
+	Stdout, Stderr io.Writer
+	// Summary receives the table of exit codes. Nil means os.Stderr.
+	Summary io.Writer
+	// Color colors the prefix of each profile
+	Color bool
+}
+
+// Run runs args, inline variables followed by the command, for each profile
+// and writes a summary of the exit codes. It returns the error of the first
+// profile, in order, for which the command failed.
+func (r *ProfileRunner) Run(ctx context.Context, args []string) error {
+	logger := ctxlog.From(ctx)
+
+	if _, command, _ := parseInlineEnvVars(args); command == "" {
+		return goerr.New("running across profiles requires a command")
+	}
+
+	stdout, stderr, summary := r.Stdout, r.Stderr, r.Summary
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	if summary == nil {
+		summary = os.Stderr
+	}
+
+	width := 0
+	for _, profile := range r.Profiles {
+		width = max(width, len(profile))
+	}
+	var mu sync.Mutex
+
+	// The executor relays these to the command; here they only keep the
+	// remaining profiles from running.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sigCh)
+
+	errs := make([]error, len(r.Profiles))
+	ran := make([]bool, len(r.Profiles))
+	run := func(i int) {
+		profile := r.Profiles[i]
+		prefix := prefixLabel(profile, width, i, r.Color)
+		out := &prefixWriter{out: stdout, mu: &mu, prefix: prefix}
+		errOut := &prefixWriter{out: stderr, mu: &mu, prefix: prefix}
+
+		runCtx := executor.ContextWithOutput(ctx, out, errOut)
+		if r.Parallel {
+			// Commands must not compete for zenv's stdin
+			runCtx = executor.ContextWithStdin(runCtx, strings.NewReader(""))
+		}
+		logger.Info("running command for profile", "profile", profile)
+		errs[i] = r.NewUseCase(profile).Run(runCtx, args)
+		ran[i] = true
+		out.Flush()
+		errOut.Flush()
+	}
+
+	if r.Parallel {
+		var wg sync.WaitGroup
+		for i := range r.Profiles {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(i)
+			}()
+		}
+		wg.Wait()
+	} else {
+	loop:
+		for i := range r.Profiles {
+			select {
+			case <-sigCh:
+				break loop
+			default:
+			}
+			if ctx.Err() != nil {
+				break loop
+			}
+			run(i)
+		}
+	}
+
+	writeProfileSummary(summary, r.Profiles, errs, ran, width)
+
+	for i, err := range errs {
+		if err != nil {
+			return goerr.Wrap(err, "command failed for profile", goerr.V("profile", r.Profiles[i]))
+		}
+	}
+	return nil
+}
+
+// writeProfileSummary writes the exit code of the command for each profile,
+// or that it was skipped
+func writeProfileSummary(w io.Writer, profiles []string, errs []error, ran []bool, width int) {
+	width = max(width, len("PROFILE"))
+	fmt.Fprintf(w, "%-*s  EXIT CODE\n", width, "PROFILE")
+	for i, profile := range profiles {
+		result := "skipped"
+		if ran[i] {
+			result = fmt.Sprint(model.GetExitCode(errs[i]))
+		}
+		fmt.Fprintf(w, "%-*s  %s\n", width, profile, result)
+	}
+}
@@ -0,0 +1,51 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestProfileRunner(t *testing.T) {
+	newRunner := func(parallel bool) (*usecase.ProfileRunner, *bytes.Buffer, *bytes.Buffer, *bytes.Buffer) {
+		var stdout, stderr, summary bytes.Buffer
+		return &usecase.ProfileRunner{
+			Profiles: []string{"dev", "staging", "prod"},
+			Parallel: parallel,
+			NewUseCase: func(profile string) *usecase.UseCase {
+				hostLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+					return []*model.EnvVar{{Name: "HOST", Value: profile + ".example.com", Source: model.SourceYAML}}, nil
+				}
+				return usecase.NewUseCase([]loader.LoadFunc{hostLoader}, executor.NewDefaultExecutor())
+			},
+			Stdout:  &stdout,
+			Stderr:  &stderr,
+			Summary: &summary,
+		}, &stdout, &stderr, &summary
+	}
+	script := `echo "host $HOST"; echo "warn $NAME" >&2; [ "$HOST" != staging.example.com ] || exit 2`
+
+	for _, parallel := range []bool{false, true} {
+		runner, stdout, stderr, summary := newRunner(parallel)
+		err := runner.Run(context.Background(), []string{"NAME=zenv", "sh", "-c", script})
+		gt.Error(t, err)
+		gt.Equal(t, model.GetExitCode(err), 2)
+
+		lines := strings.Split(stdout.String(), "\n")
+		gt.A(t, lines).Has("dev     | host dev.example.com").Has("staging | host staging.example.com").Has("prod    | host prod.example.com")
+		gt.S(t, stderr.String()).Contains("prod    | warn zenv\n")
+		gt.Equal(t, summary.String(), "PROFILE  EXIT CODE\ndev      0\nstaging  2\nprod     0\n")
+	}
+
+	t.Run("requires a command", func(t *testing.T) {
+		runner, _, _, _ := newRunner(false)
+		gt.Error(t, runner.Run(context.Background(), []string{"NAME=zenv"}))
+	})
+}
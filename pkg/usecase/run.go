@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/m-mizutani/ctxlog"
 	"github.com/m-mizutani/goerr/v2"
@@ -13,12 +15,46 @@ import (
 	"github.com/m-mizutani/zenv/v2/pkg/expander"
 	"github.com/m-mizutani/zenv/v2/pkg/loader"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
 )
 
 type UseCase struct {
 	Loaders        []loader.LoadFunc
 	Executor       executor.ExecuteFunc
 	EnableTemplate bool
+	// AllowSecretArgs permits template expansion to put secret values into
+	// command arguments.
+	AllowSecretArgs bool
+	// Classifier marks variables from any source as secret by name and value.
+	// Nil disables automatic classification.
+	Classifier *SecretClassifier
+	// IgnoreEnvironment drops all system environment variables, like env -i
+	IgnoreEnvironment bool
+	// Unset lists system environment variables to drop, like env -u
+	Unset []string
+	// Passthrough filters system environment variables by name. Nil passes all.
+	Passthrough *model.Passthrough
+	// Report, if set, receives what the run did: the loaded files, the
+	// variables, the command and its timing. The caller records the result.
+	Report *model.Report
+	// ReportValues includes the values of non-secret variables in Report
+	ReportValues bool
+	// MaskPolicy decides how secret values are shown in the variable list
+	MaskPolicy redact.Policy
+	// Watch restarts the command when a file that contributed to the
+	// environment changes
+	Watch bool
+	// WatchDebounce is how long files must stay unchanged before the command
+	// is restarted. Zero means DefaultWatchDebounce.
+	WatchDebounce time.Duration
+	// Hooks, if set, are run before and after the command
+	Hooks *model.Hooks
+	// HookExecutor runs the hooks. It should not retry, time out or log
+	// output like Executor does for the command. Nil means Executor.
+	HookExecutor executor.ExecuteFunc
+	// Secrets receives the values of secret variables once they are resolved,
+	// so that logs and error messages can mask them. Optional.
+	Secrets *redact.Secrets
 }
 
 func NewUseCase(loaders []loader.LoadFunc, exec executor.ExecuteFunc) *UseCase {
@@ -30,19 +66,50 @@ func NewUseCase(loaders []loader.LoadFunc, exec executor.ExecuteFunc) *UseCase {
 
 func (uc *UseCase) Run(ctx context.Context, args []string) error {
 	logger := ctxlog.From(ctx)
-	logger.Debug("starting zenv run", "args", args)
 
-	// Parse inline environment variables and command
-	inlineEnvVars, command, commandArgs := parseInlineEnvVars(args)
+	// Parse inline environment variables and command before logging args,
+	// so that KEY:=value secrets are masked
+	inlineEnvVars, command, commandArgs := uc.parseInline(args)
+	logger.Debug("starting zenv run", "args", args)
 	logger.Debug("parsed arguments", "inline_vars", len(inlineEnvVars), "command", command, "command_args", commandArgs)
 
+	if uc.Watch {
+		if command == "" {
+			return goerr.New("watch mode requires a command")
+		}
+		return uc.watch(ctx, inlineEnvVars, command, commandArgs)
+	}
+
+	mergedEnvVars, _, err := uc.loadEnvVars(ctx, inlineEnvVars)
+	if err != nil {
+		return err
+	}
+
+	// If no command is specified, show environment variables
+	if command == "" {
+		logger.Info("displaying environment variables", "count", len(mergedEnvVars))
+		showEnvVars(mergedEnvVars, uc.MaskPolicy)
+		return nil
+	}
+
+	return uc.execute(ctx, command, commandArgs, mergedEnvVars)
+}
+
+// loadEnvVars resolves the variables passed to the command from the system
+// environment, the loaders and inline arguments. It also returns the files
+// the loaders read or looked for.
+func (uc *UseCase) loadEnvVars(ctx context.Context, inlineEnvVars []*model.EnvVar) ([]*model.EnvVar, []string, error) {
+	logger := ctxlog.From(ctx)
+	recorder := loader.NewFileRecorder()
+	ctx = loader.ContextWithFileRecorder(ctx, recorder)
+
 	// Load environment variables from all loaders
 	var allEnvVars []*model.EnvVar
 
-	// Add system environment variables
+	// Add system environment variables that pass the filters
 	for _, env := range os.Environ() {
 		parts := strings.SplitN(env, "=", 2)
-		if len(parts) == 2 {
+		if len(parts) == 2 && uc.passesSystemVar(parts[0]) {
 			allEnvVars = append(allEnvVars, &model.EnvVar{
 				Name:   parts[0],
 				Value:  parts[1],
@@ -55,7 +122,7 @@ func (uc *UseCase) Run(ctx context.Context, args []string) error {
 	for _, loadFunc := range uc.Loaders {
 		envVars, err := loadFunc(ctx)
 		if err != nil {
-			return goerr.Wrap(err, "failed to load environment variables")
+			return nil, recorder.Paths(), goerr.Wrap(err, "failed to load environment variables")
 		}
 		allEnvVars = append(allEnvVars, envVars...)
 	}
@@ -64,30 +131,101 @@ func (uc *UseCase) Run(ctx context.Context, args []string) error {
 	allEnvVars = append(allEnvVars, inlineEnvVars...)
 	logger.Debug("loaded environment variables", "total", len(allEnvVars))
 
+	if uc.Classifier != nil {
+		uc.Classifier.Classify(allEnvVars)
+	}
+
 	// Merge environment variables (later sources override earlier ones)
 	mergedEnvVars := mergeEnvVars(allEnvVars)
 	logger.Debug("merged environment variables", "final_count", len(mergedEnvVars))
 
-	// If no command is specified, show environment variables
-	if command == "" {
-		logger.Info("displaying environment variables", "count", len(mergedEnvVars))
-		showEnvVars(mergedEnvVars)
-		return nil
+	if uc.Secrets != nil {
+		for _, envVar := range allEnvVars {
+			if envVar.Secret {
+				uc.Secrets.Add(envVar.Value)
+			}
+		}
+	}
+
+	files := recorder.Paths()
+	if uc.Report != nil {
+		uc.reportEnvironment(files, mergedEnvVars)
+	}
+	return mergedEnvVars, files, nil
+}
+
+// execute runs the hooks around the command, if any
+func (uc *UseCase) execute(ctx context.Context, command string, commandArgs []string, mergedEnvVars []*model.EnvVar) error {
+	if uc.Hooks == nil {
+		return uc.executeCommand(ctx, command, commandArgs, mergedEnvVars)
+	}
+
+	envVars, err := uc.runBeforeHooks(ctx, mergedEnvVars)
+	if err == nil {
+		cmdCtx := ctx
+		if len(uc.Hooks.After) > 0 {
+			// zenv must outlive the command to run the after hooks
+			cmdCtx = executor.ContextWithSpawn(ctx, "after hooks")
+		}
+		err = uc.executeCommand(cmdCtx, command, commandArgs, envVars)
+	} else {
+		// Variables exported before the failure do not reach the after hooks
+		envVars = mergedEnvVars
 	}
 
+	if err == nil || uc.Hooks.AlwaysAfter {
+		if hookErr := uc.runAfterHooks(ctx, envVars); hookErr != nil {
+			if err != nil {
+				ctxlog.From(ctx).Warn("after hook failed", "error", hookErr)
+			} else {
+				err = hookErr
+			}
+		}
+	}
+	return err
+}
+
+// executeCommand expands the command arguments if template mode is enabled and runs the command
+func (uc *UseCase) executeCommand(ctx context.Context, command string, commandArgs []string, mergedEnvVars []*model.EnvVar) error {
+	logger := ctxlog.From(ctx)
+
 	// Expand command arguments if template mode is enabled
 	finalArgs := commandArgs
 	if uc.EnableTemplate {
 		logger.Debug("template expansion enabled, expanding arguments")
 		exp := expander.NewExpander(mergedEnvVars)
+		exp.AllowSecretArgs = uc.AllowSecretArgs
+		defer func() {
+			if err := exp.Close(); err != nil {
+				logger.Warn("failed to remove template argument files", "error", err)
+			}
+		}()
 		expandedArgs, err := exp.ExpandArgs(ctx, commandArgs)
 		if err != nil {
 			return goerr.Wrap(err, "failed to expand template arguments")
 		}
 		finalArgs = expandedArgs
+		if exp.HasFiles() {
+			ctx = executor.ContextWithSpawn(ctx, "template file cleanup")
+		}
+		if stdin, ok := exp.Stdin(); ok {
+			ctx = executor.ContextWithStdin(ctx, strings.NewReader(stdin))
+		}
 		logger.Debug("arguments expanded", "original", commandArgs, "expanded", finalArgs)
 	}
 
+	if uc.Report != nil {
+		// zenv has to outlive the command to finish the report
+		ctx = executor.ContextWithSpawn(ctx, "report")
+		uc.Report.Command = command
+		uc.Report.Args = uc.scrubArgs(finalArgs)
+		uc.Report.StartedAt = time.Now()
+		defer func() {
+			uc.Report.EndedAt = time.Now()
+			uc.Report.DurationSeconds = uc.Report.EndedAt.Sub(uc.Report.StartedAt).Seconds()
+		}()
+	}
+
 	// Execute command with environment variables
 	logger.Info("executing command", "command", command, "args", finalArgs, "env_vars", len(mergedEnvVars))
 	err := uc.Executor(ctx, command, finalArgs, mergedEnvVars)
@@ -99,6 +237,65 @@ func (uc *UseCase) Run(ctx context.Context, args []string) error {
 	return nil
 }
 
+// passesSystemVar reports whether the system environment variable name is
+// passed to the command
+func (uc *UseCase) passesSystemVar(name string) bool {
+	if uc.IgnoreEnvironment || slices.Contains(uc.Unset, name) {
+		return false
+	}
+	return uc.Passthrough.Allows(name)
+}
+
+// reportEnvironment records the loaded files and the final variables in uc.Report
+func (uc *UseCase) reportEnvironment(files []string, envVars []*model.EnvVar) {
+	uc.Report.Files = []string{}
+	for _, f := range files {
+		if _, err := os.Stat(f); err == nil {
+			uc.Report.Files = append(uc.Report.Files, f)
+		}
+	}
+
+	sorted := slices.Clone(envVars)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	uc.Report.Variables = make(map[string][]model.ReportVariable)
+	for _, envVar := range sorted {
+		v := model.ReportVariable{Name: envVar.Name, Secret: envVar.Secret}
+		if uc.ReportValues && !envVar.Secret {
+			v.Value = &envVar.Value
+		}
+		source := envVar.Source.String()
+		uc.Report.Variables[source] = append(uc.Report.Variables[source], v)
+	}
+}
+
+// scrubArgs masks secret values in command arguments, which may contain
+// them with AllowSecretArgs
+func (uc *UseCase) scrubArgs(args []string) []string {
+	if uc.Secrets == nil {
+		return args
+	}
+	scrubbed := make([]string, len(args))
+	for i, arg := range args {
+		scrubbed[i] = uc.Secrets.Scrub(arg)
+	}
+	return scrubbed
+}
+
+// parseInline parses inline variables and the command, and registers the
+// values of secret inline variables with Secrets.
+func (uc *UseCase) parseInline(args []string) ([]*model.EnvVar, string, []string) {
+	inlineEnvVars, command, commandArgs := parseInlineEnvVars(args)
+	if uc.Secrets != nil {
+		for _, envVar := range inlineEnvVars {
+			if envVar.Secret {
+				uc.Secrets.Add(envVar.Value)
+			}
+		}
+	}
+	return inlineEnvVars, command, commandArgs
+}
+
 func parseInlineEnvVars(args []string) ([]*model.EnvVar, string, []string) {
 	var inlineEnvVars []*model.EnvVar
 	commandStart := -1
@@ -107,10 +304,13 @@ func parseInlineEnvVars(args []string) ([]*model.EnvVar, string, []string) {
 		if strings.Contains(arg, "=") {
 			parts := strings.SplitN(arg, "=", 2)
 			if len(parts) == 2 {
+				// KEY:=value marks the variable as secret
+				name, secret := strings.CutSuffix(parts[0], ":")
 				inlineEnvVars = append(inlineEnvVars, &model.EnvVar{
-					Name:   parts[0],
+					Name:   name,
 					Value:  parts[1],
 					Source: model.SourceInline,
+					Secret: secret,
 				})
 				continue
 			}
@@ -141,7 +341,7 @@ func mergeEnvVars(envVars []*model.EnvVar) []*model.EnvVar {
 	return result
 }
 
-func showEnvVars(envVars []*model.EnvVar) {
+func showEnvVars(envVars []*model.EnvVar, policy redact.Policy) {
 	// Create a copy to avoid modifying the input slice
 	varsToShow := make([]*model.EnvVar, len(envVars))
 	copy(varsToShow, envVars)
@@ -152,23 +352,16 @@ func showEnvVars(envVars []*model.EnvVar) {
 	})
 
 	for _, envVar := range varsToShow {
-		var sourceStr string
-		switch envVar.Source {
-		case model.SourceSystem:
-			sourceStr = "system"
-		case model.SourceDotEnv:
-			sourceStr = ".env"
-		case model.SourceYAML:
-			sourceStr = ".yaml"
-		case model.SourceHCL:
-			sourceStr = ".hcl"
-		case model.SourceInline:
-			sourceStr = "inline"
+		sourceStr := envVar.Source.String()
+		if !envVar.Secret {
+			fmt.Printf("%s=%s [%s]\n", envVar.Name, envVar.Value, sourceStr)
+			continue
 		}
-		displayValue := envVar.Value
-		if envVar.Secret {
-			displayValue = strings.Repeat("*", len(envVar.Value))
+
+		reason := envVar.SecretReason
+		if reason == "" {
+			reason = "declared"
 		}
-		fmt.Printf("%s=%s [%s]\n", envVar.Name, displayValue, sourceStr)
+		fmt.Printf("%s=%s [%s] (secret: %s)\n", envVar.Name, policy.Mask(envVar.Name, envVar.Value), sourceStr, reason)
 	}
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/m-mizutani/zenv/v2/pkg/executor"
 	"github.com/m-mizutani/zenv/v2/pkg/loader"
 	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
 	"github.com/m-mizutani/zenv/v2/pkg/usecase"
 )
 
@@ -364,6 +365,104 @@ func TestUseCase(t *testing.T) {
 		gt.Equal(t, executedArgs[5], "5432")
 	})
 
+	t.Run("Filter system environment variables", func(t *testing.T) {
+		t.Setenv("ZENV_TEST_KEEP", "keep")
+		t.Setenv("ZENV_TEST_DROP", "drop")
+		t.Setenv("ZENV_TEST_DENIED", "denied")
+
+		var names map[string]bool
+		mockExecutor := func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+			names = make(map[string]bool)
+			for _, envVar := range envVars {
+				names[envVar.Name] = true
+			}
+			return nil
+		}
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{{Name: "FROM_FILE", Value: "x", Source: model.SourceDotEnv}}, nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, mockExecutor)
+		uc.Unset = []string{"ZENV_TEST_DROP"}
+		uc.Passthrough = &model.Passthrough{Allow: []string{"ZENV_TEST_*"}, Deny: []string{"*_DENIED"}}
+		gt.NoError(t, uc.Run(context.Background(), []string{"INLINE=1", "cmd"}))
+
+		gt.True(t, names["ZENV_TEST_KEEP"])
+		gt.False(t, names["ZENV_TEST_DROP"])
+		gt.False(t, names["ZENV_TEST_DENIED"])
+		gt.False(t, names["PATH"])
+		gt.True(t, names["FROM_FILE"])
+		gt.True(t, names["INLINE"])
+
+		uc = usecase.NewUseCase([]loader.LoadFunc{mockLoader}, mockExecutor)
+		uc.IgnoreEnvironment = true
+		gt.NoError(t, uc.Run(context.Background(), []string{"cmd"}))
+		gt.Equal(t, names, map[string]bool{"FROM_FILE": true})
+	})
+
+	t.Run("Template expansion refuses secret values in arguments", func(t *testing.T) {
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{
+				{Name: "DB_PASS", Value: "hunter2", Source: model.SourceYAML, Secret: true},
+			}, nil
+		}
+
+		var executedArgs []string
+		mockExecutor := func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+			executedArgs = args
+			return nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, mockExecutor)
+		uc.EnableTemplate = true
+
+		err := uc.Run(context.Background(), []string{"mysql", "-p{{ .DB_PASS }}"})
+		gt.Error(t, err)
+		gt.Nil(t, executedArgs)
+
+		uc.AllowSecretArgs = true
+		gt.NoError(t, uc.Run(context.Background(), []string{"mysql", "-p{{ .DB_PASS }}"}))
+		gt.Equal(t, executedArgs, []string{"-phunter2"})
+	})
+
+	t.Run("Template asStdin feeds secret value to command stdin", func(t *testing.T) {
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{
+				{Name: "DB_PASS", Value: "hunter2", Source: model.SourceYAML, Secret: true},
+			}, nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, executor.NewDefaultExecutor())
+		uc.EnableTemplate = true
+
+		err := uc.Run(context.Background(), []string{"sh", "-c", `read -r v < {{ asStdin .DB_PASS }}; [ "$v" = "$DB_PASS" ]`})
+		gt.NoError(t, err)
+	})
+
+	t.Run("Template asFile file is removed after command exits", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{
+				{Name: "DB_PASS", Value: "hunter2", Source: model.SourceYAML, Secret: true},
+			}, nil
+		}
+
+		var path, content string
+		mockExecutor := func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+			path = args[0]
+			content = string(gt.R1(os.ReadFile(path)).NoError(t))
+			return nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, mockExecutor)
+		uc.EnableTemplate = true
+
+		gt.NoError(t, uc.Run(context.Background(), []string{"cat", "{{ asFile .DB_PASS }}"}))
+		gt.Equal(t, content, "hunter2")
+		_, err := os.Stat(path)
+		gt.True(t, os.IsNotExist(err))
+	})
+
 	t.Run("Environment variables are sorted alphabetically when displayed", func(t *testing.T) {
 		output := testShowEnvVarsOutput(t, []*model.EnvVar{
 			{Name: "ZEBRA", Value: "last", Source: model.SourceDotEnv},
@@ -405,12 +504,108 @@ func TestUseCase(t *testing.T) {
 			{Name: "APP_NAME", Value: "my-app", Source: model.SourceYAML, Secret: false},
 		})
 
-		// "super-secret" is 12 chars, so masked value should be 12 asterisks
-		gt.S(t, output).Contains("DB_PASS=************ [.yaml]")
+		// The fixed mask does not reveal the length of "super-secret"
+		gt.S(t, output).Contains("DB_PASS=***** [.yaml]")
 		gt.S(t, output).Contains("APP_NAME=my-app [.yaml]")
 		gt.S(t, output).NotContains("super-secret")
 	})
 
+	t.Run("Secret variables are masked with mask policy", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		gt.NoError(t, err)
+
+		oldStdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = oldStdout }()
+
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{
+				{Name: "DB_PASS", Value: "super-secret", Source: model.SourceYAML, Secret: true},
+			}, nil
+		}
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, executor.NewDefaultExecutor())
+		uc.MaskPolicy = redact.Policy{Kind: redact.PolicyName}
+		gt.NoError(t, uc.Run(context.Background(), []string{}))
+
+		w.Close()
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+		gt.S(t, output).Contains("DB_PASS=[REDACTED:DB_PASS] [.yaml]")
+		gt.S(t, output).NotContains("super-secret")
+	})
+
+	t.Run("Secret classification reason is displayed", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		gt.NoError(t, err)
+
+		oldStdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = oldStdout }()
+
+		mockLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{
+				{Name: "DB_PASS", Value: "super-secret", Source: model.SourceYAML, Secret: true},
+				{Name: "GITHUB_TOKEN", Value: "ghp_abc", Source: model.SourceDotEnv},
+			}, nil
+		}
+		uc := usecase.NewUseCase([]loader.LoadFunc{mockLoader}, executor.NewDefaultExecutor())
+		uc.Classifier = usecase.NewSecretClassifier()
+
+		gt.NoError(t, uc.Run(context.Background(), []string{"API_TOKEN=inline-tok"}))
+
+		w.Close()
+		output := string(gt.R1(io.ReadAll(r)).NoError(t))
+
+		gt.S(t, output).Contains("DB_PASS=***** [.yaml] (secret: declared)")
+		gt.S(t, output).Contains("GITHUB_TOKEN=***** [.env] (secret: name matches *_TOKEN)")
+		gt.S(t, output).Contains("API_TOKEN=***** [inline] (secret: name matches *_TOKEN)")
+		gt.S(t, output).NotContains("ghp_abc")
+		gt.S(t, output).NotContains("inline-tok")
+	})
+
+	t.Run("Classified variables are passed to executor as secret", func(t *testing.T) {
+		var executedEnvVars []*model.EnvVar
+
+		mockExecutor := func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+			executedEnvVars = envVars
+			return nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{}, mockExecutor)
+		uc.Classifier = usecase.NewSecretClassifier()
+
+		gt.NoError(t, uc.Run(context.Background(), []string{"MY_API_KEY=abc", "PLAIN=abc", "echo"}))
+
+		for _, envVar := range executedEnvVars {
+			switch envVar.Name {
+			case "MY_API_KEY":
+				gt.True(t, envVar.Secret)
+			case "PLAIN":
+				gt.False(t, envVar.Secret)
+			}
+		}
+	})
+
+	t.Run("Inline KEY:=value is a secret variable", func(t *testing.T) {
+		var executedEnvVars []*model.EnvVar
+
+		mockExecutor := func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+			executedEnvVars = envVars
+			return nil
+		}
+
+		uc := usecase.NewUseCase([]loader.LoadFunc{}, mockExecutor)
+		gt.NoError(t, uc.Run(context.Background(), []string{"PASS:=a=b", "USER=admin", "echo"}))
+
+		envMap := make(map[string]*model.EnvVar)
+		for _, envVar := range executedEnvVars {
+			envMap[envVar.Name] = envVar
+		}
+		gt.Equal(t, envMap["PASS"].Value, "a=b")
+		gt.True(t, envMap["PASS"].Secret)
+		gt.Equal(t, envMap["USER"].Value, "admin")
+		gt.False(t, envMap["USER"].Secret)
+	})
+
 	t.Run("Secret variables pass real value to executor", func(t *testing.T) {
 		var executedEnvVars []*model.EnvVar
 
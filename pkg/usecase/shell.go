@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/secretfile"
+)
+
+const (
+	// ActiveEnvName marks the environment of a zenv shell
+	ActiveEnvName = "ZENV_ACTIVE"
+	// ProfileEnvName holds the profile of a zenv shell
+	ProfileEnvName = "ZENV_PROFILE"
+
+	// defaultShell is started when $SHELL is not set
+	defaultShell = "/bin/sh"
+)
+
+// The startup files below prefix the user's prompt with "(zenv) " or
+// "(zenv:PROFILE) " after running the user's own startup files. The profile
+// is read from the environment so that it is never parsed as shell code.
+
+const bashRC = `if [ -f ~/.bashrc ]; then . ~/.bashrc; fi
+PS1="(zenv${ZENV_PROFILE:+:$ZENV_PROFILE}) ${PS1-}"
+`
+
+// zsh reads its startup files from ZDOTDIR, which points to zenv's directory
+// until the user's .zshrc is run
+const zshEnv = `if [ -f "${ZENV_ZDOTDIR:-$HOME}/.zshenv" ]; then . "${ZENV_ZDOTDIR:-$HOME}/.zshenv"; fi
+`
+
+const zshRC = `if [ -n "${ZENV_ZDOTDIR+x}" ]; then ZDOTDIR="$ZENV_ZDOTDIR"; else unset ZDOTDIR; fi
+unset ZENV_ZDOTDIR
+if [ -f "${ZDOTDIR:-$HOME}/.zshrc" ]; then . "${ZDOTDIR:-$HOME}/.zshrc"; fi
+PROMPT="(zenv${ZENV_PROFILE:+:$ZENV_PROFILE}) ${PROMPT-}"
+`
+
+const fishInit = `functions -c fish_prompt _zenv_fish_prompt
+function fish_prompt
+    if test -n "$ZENV_PROFILE"
+        echo -n "(zenv:$ZENV_PROFILE) "
+    else
+        echo -n "(zenv) "
+    end
+    _zenv_fish_prompt
+end`
+
+// Shell starts $SHELL interactively with the resolved environment, marked
+// with ZENV_ACTIVE and ZENV_PROFILE, and a prompt prefix for bash, zsh and
+// fish. Inline variables (KEY=value) in inline are set too.
+func (uc *UseCase) Shell(ctx context.Context, inline []string, profile string) error {
+	logger := ctxlog.From(ctx)
+
+	if _, active := os.LookupEnv(ActiveEnvName); active {
+		logger.Warn("starting a zenv shell inside another one", "outer_profile", os.Getenv(ProfileEnvName))
+	}
+
+	inlineEnvVars, _, _ := uc.parseInline(inline)
+	envVars, _, err := uc.loadEnvVars(ctx, inlineEnvVars)
+	if err != nil {
+		return err
+	}
+	envVars = mergeEnvVars(append(envVars,
+		&model.EnvVar{Name: ActiveEnvName, Value: "1", Source: model.SourceInline},
+		&model.EnvVar{Name: ProfileEnvName, Value: profile, Source: model.SourceInline},
+	))
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = defaultShell
+	}
+
+	var args []string
+	var files *secretfile.Dir
+	switch filepath.Base(shell) {
+	case "bash":
+		if files, err = writeStartupFiles(map[string]string{"bashrc": bashRC}); err != nil {
+			return err
+		}
+		args = []string{"--rcfile", filepath.Join(files.Path(), "bashrc"), "-i"}
+
+	case "zsh":
+		if files, err = writeStartupFiles(map[string]string{".zshenv": zshEnv, ".zshrc": zshRC}); err != nil {
+			return err
+		}
+		if zdotdir, ok := os.LookupEnv("ZDOTDIR"); ok {
+			envVars = append(envVars, &model.EnvVar{Name: "ZENV_ZDOTDIR", Value: zdotdir, Source: model.SourceInline})
+		}
+		envVars = mergeEnvVars(append(envVars, &model.EnvVar{Name: "ZDOTDIR", Value: files.Path(), Source: model.SourceInline}))
+		args = []string{"-i"}
+
+	case "fish":
+		args = []string{"--init-command", fishInit}
+
+	default:
+		logger.Debug("no prompt prefix for shell", "shell", shell)
+		args = []string{"-i"}
+	}
+
+	if files != nil {
+		// zenv removes the startup files when the shell exits
+		ctx = executor.ContextWithSpawn(ctx, "shell startup files")
+		defer func() {
+			if err := files.Remove(); err != nil {
+				logger.Warn("failed to remove shell startup files", "error", err)
+			}
+		}()
+	}
+
+	logger.Info("starting shell", "shell", shell, "profile", profile)
+	return uc.execute(ctx, shell, args, envVars)
+}
+
+// writeStartupFiles writes shell startup files to a private directory
+func writeStartupFiles(startup map[string]string) (*secretfile.Dir, error) {
+	files, err := secretfile.NewDir()
+	if err != nil {
+		return nil, goerr.Wrap(err, "failed to create shell startup files")
+	}
+	for name, content := range startup {
+		if _, err := files.Write(name, content, 0o600); err != nil {
+			_ = files.Remove()
+			return nil, goerr.Wrap(err, "failed to create shell startup files")
+		}
+	}
+	return files, nil
+}
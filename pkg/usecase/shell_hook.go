@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// HookStateEnvName holds what the shell hook exported, so that it can be
+// reverted and reloaded only when the configuration changes
+const HookStateEnvName = "ZENV_HOOK_STATE"
+
+// hookShells are the shells supported by HookScript and Export
+var hookShells = []string{"bash", "zsh", "fish"}
+
+// hookState is kept in the shell, encoded in HookStateEnvName
+type hookState struct {
+	// Files are the files the environment was loaded from, including
+	// missing ones that were looked for
+	Files map[string]hookStamp `json:"files"`
+	// Prev holds the values of the exported variables before loading; nil
+	// means the variable was not set
+	Prev map[string]*string `json:"prev"`
+}
+
+type hookStamp struct {
+	Exists  bool  `json:"exists,omitempty"`
+	Size    int64 `json:"size,omitempty"`
+	ModTime int64 `json:"mtime,omitempty"`
+}
+
+func newHookStamps(stamps map[string]fileStamp) map[string]hookStamp {
+	hs := make(map[string]hookStamp, len(stamps))
+	for p, s := range stamps {
+		if s.exists {
+			hs[p] = hookStamp{Exists: true, Size: s.size, ModTime: s.modTime.UnixNano()}
+		} else {
+			hs[p] = hookStamp{}
+		}
+	}
+	return hs
+}
+
+func checkShell(shell string) error {
+	if slices.Contains(hookShells, shell) {
+		return nil
+	}
+	return goerr.New("unsupported shell, expected bash, zsh or fish", goerr.V("shell", shell))
+}
+
+// HookScript returns the shell code that makes shell run command, followed
+// by "export SHELL", before each prompt and evaluate its output
+func HookScript(shell string, command []string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	quoted := make([]string, 0, len(command)+2)
+	for _, arg := range append(command, "export", shell) {
+		quoted = append(quoted, shellQuote(shell, arg))
+	}
+	call := strings.Join(quoted, " ")
+
+	switch shell {
+	case "bash":
+		return `_zenv_hook() {
+  local previous_exit_status=$?
+  eval "$(` + call + `)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_zenv_hook;"* ]]; then
+  PROMPT_COMMAND="_zenv_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`, nil
+	case "zsh":
+		return `_zenv_hook() {
+  eval "$(` + call + `)"
+}
+typeset -ag precmd_functions chpwd_functions
+if (( ! ${precmd_functions[(I)_zenv_hook]} )); then
+  precmd_functions=(_zenv_hook $precmd_functions)
+fi
+if (( ! ${chpwd_functions[(I)_zenv_hook]} )); then
+  chpwd_functions=(_zenv_hook $chpwd_functions)
+fi
+`, nil
+	default:
+		return `function _zenv_hook --on-event fish_prompt --on-variable PWD
+    ` + call + ` | source
+end
+`, nil
+	}
+}
+
+// Export writes to w the shell code that brings the shell's environment in
+// line with the configuration files in discovered. Variables exported before
+// are reverted when no file exists any more. The environment is only resolved
+// again when a file it was loaded from changed, so that command sources do
+// not run on every prompt.
+func (uc *UseCase) Export(ctx context.Context, shell string, discovered []string, w io.Writer) error {
+	logger := ctxlog.From(ctx)
+	if err := checkShell(shell); err != nil {
+		return err
+	}
+
+	state := decodeHookState(ctx, os.Getenv(HookStateEnvName))
+
+	var existing []string
+	for _, p := range discovered {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if _, err := os.Stat(p); err == nil {
+			existing = append(existing, p)
+		}
+	}
+
+	if len(existing) == 0 {
+		if state == nil {
+			return nil
+		}
+		fmt.Fprintln(os.Stderr, "zenv: unloading")
+		final := make(map[string]*string, len(state.Prev)+1)
+		for name, prev := range state.Prev {
+			final[name] = prev
+		}
+		final[HookStateEnvName] = nil
+		writeAssignments(w, shell, final)
+		return nil
+	}
+
+	if state != nil && state.covers(existing) {
+		paths := make([]string, 0, len(state.Files))
+		for p := range state.Files {
+			paths = append(paths, p)
+		}
+		if hookStampsEqual(newHookStamps(statFiles(paths)), state.Files) {
+			logger.Debug("environment is up to date", "files", existing)
+			return nil
+		}
+	}
+
+	// Resolve against the environment as it was before the previous load
+	if state != nil {
+		for name, prev := range state.Prev {
+			if prev == nil {
+				_ = os.Unsetenv(name)
+			} else {
+				_ = os.Setenv(name, *prev)
+			}
+		}
+	}
+
+	envVars, files, err := uc.loadEnvVars(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	next := &hookState{Files: newHookStamps(statFiles(files)), Prev: make(map[string]*string)}
+	final := make(map[string]*string)
+	var changes []string
+	for _, envVar := range envVars {
+		if !exportName.MatchString(envVar.Name) || envVar.Name == HookStateEnvName {
+			logger.Debug("skipping variable that cannot be exported", "name", envVar.Name)
+			continue
+		}
+		if envVar.AsFile {
+			logger.Warn("as_file variables are not exported to the shell", "name", envVar.Name)
+			continue
+		}
+		cur, ok := os.LookupEnv(envVar.Name)
+		if ok && cur == envVar.Value {
+			continue
+		}
+		if ok {
+			next.Prev[envVar.Name] = &cur
+			changes = append(changes, "~"+envVar.Name)
+		} else {
+			next.Prev[envVar.Name] = nil
+			changes = append(changes, "+"+envVar.Name)
+		}
+		final[envVar.Name] = &envVar.Value
+	}
+
+	// Variables that are no longer set by the configuration are reverted
+	if state != nil {
+		for name, prev := range state.Prev {
+			if _, ok := final[name]; !ok {
+				final[name] = prev
+			}
+		}
+	}
+
+	encoded, err := encodeHookState(next)
+	if err != nil {
+		return err
+	}
+	final[HookStateEnvName] = &encoded
+
+	sort.Strings(changes)
+	fmt.Fprintf(os.Stderr, "zenv: loading %s\n", strings.Join(existing, ", "))
+	if len(changes) > 0 {
+		fmt.Fprintf(os.Stderr, "zenv: export %s\n", strings.Join(changes, " "))
+	}
+	writeAssignments(w, shell, final)
+	return nil
+}
+
+// covers reports whether the environment was loaded from all paths
+func (s *hookState) covers(paths []string) bool {
+	for _, p := range paths {
+		if stamp, ok := s.Files[p]; !ok || !stamp.Exists {
+			return false
+		}
+	}
+	return true
+}
+
+func hookStampsEqual(a, b map[string]hookStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, s := range a {
+		if t, ok := b[p]; !ok || s != t {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeHookState(state *hookState) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", goerr.Wrap(err, "failed to encode shell hook state")
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeHookState returns nil if there is no valid state
+func decodeHookState(ctx context.Context, encoded string) *hookState {
+	if encoded == "" {
+		return nil
+	}
+	var state hookState
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		ctxlog.From(ctx).Warn("ignoring invalid shell hook state", "error", err)
+		return nil
+	}
+	return &state
+}
+
+// writeAssignments writes shell code that sets the variables in final, in
+// name order. A nil value unsets the variable.
+func writeAssignments(w io.Writer, shell string, final map[string]*string) {
+	names := make([]string, 0, len(final))
+	for name := range final {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := final[name]
+		switch {
+		case shell == "fish" && value == nil:
+			fmt.Fprintf(w, "set -e %s;\n", name)
+		case shell == "fish":
+			fmt.Fprintf(w, "set -gx %s %s;\n", name, shellQuote(shell, *value))
+		case value == nil:
+			fmt.Fprintf(w, "unset %s;\n", name)
+		default:
+			fmt.Fprintf(w, "export %s=%s;\n", name, shellQuote(shell, *value))
+		}
+	}
+}
+
+// shellQuote quotes s as a single word for shell
+func shellQuote(shell, s string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	gt.NoError(t, os.WriteFile(envPath, []byte("ZENV_HOOK_NEW=it's\nZENV_HOOK_EXISTING=new\n"), 0o600))
+
+	t.Setenv(usecase.HookStateEnvName, "")
+	t.Setenv("ZENV_HOOK_NEW", "")
+	os.Unsetenv("ZENV_HOOK_NEW")
+	t.Setenv("ZENV_HOOK_EXISTING", "old")
+
+	loads := 0
+	uc := usecase.NewUseCase([]loader.LoadFunc{func(ctx context.Context) ([]*model.EnvVar, error) {
+		loads++
+		return loader.NewDotEnvLoader(envPath)(ctx)
+	}}, nil)
+
+	// export runs the hook and applies its output like the shell would
+	export := func(discovered ...string) string {
+		var out bytes.Buffer
+		gt.NoError(t, uc.Export(ctx, "bash", discovered, &out))
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			line = strings.TrimSuffix(line, ";")
+			if name, ok := strings.CutPrefix(line, "unset "); ok {
+				os.Unsetenv(name)
+			} else if assign, ok := strings.CutPrefix(line, "export "); ok {
+				name, value, _ := strings.Cut(assign, "=")
+				os.Setenv(name, strings.ReplaceAll(value[1:len(value)-1], `'\''`, "'"))
+			}
+		}
+		return out.String()
+	}
+
+	output := export(envPath)
+	gt.S(t, output).Contains("export ZENV_HOOK_EXISTING='new';\n").Contains(`export ZENV_HOOK_NEW='it'\''s';` + "\n")
+	gt.Equal(t, os.Getenv("ZENV_HOOK_NEW"), "it's")
+	gt.Equal(t, loads, 1)
+
+	// Nothing changed, so the environment is not resolved again
+	gt.Equal(t, export(envPath), "")
+	gt.Equal(t, loads, 1)
+
+	gt.NoError(t, os.WriteFile(envPath, []byte("ZENV_HOOK_EXISTING=newer\n"), 0o600))
+	output = export(envPath)
+	gt.S(t, output).Contains("export ZENV_HOOK_EXISTING='newer';\n").Contains("unset ZENV_HOOK_NEW;\n")
+	gt.Equal(t, loads, 2)
+
+	// Leaving the directory reverts everything
+	output = export(filepath.Join(t.TempDir(), ".env"))
+	gt.Equal(t, output, "export ZENV_HOOK_EXISTING='old';\nunset "+usecase.HookStateEnvName+";\n")
+	gt.Equal(t, os.Getenv("ZENV_HOOK_EXISTING"), "old")
+	gt.Equal(t, export(filepath.Join(t.TempDir(), ".env")), "")
+}
+
+func TestHookScript(t *testing.T) {
+	script := gt.R1(usecase.HookScript("fish", []string{"/opt/my zenv/zenv", "-p", "it's"})).NoError(t)
+	gt.S(t, script).Contains(`'/opt/my zenv/zenv' '-p' 'it\'s' 'export' 'fish' | source`)
+
+	script = gt.R1(usecase.HookScript("bash", []string{"zenv"})).NoError(t)
+	gt.S(t, script).Contains(`eval "$('zenv' 'export' 'bash')"`)
+
+	_, err := usecase.HookScript("tcsh", []string{"zenv"})
+	gt.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestShell(t *testing.T) {
+	newUseCase := func() *usecase.UseCase {
+		hostLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{{Name: "HOST", Value: "dev.local", Source: model.SourceYAML}}, nil
+		}
+		return usecase.NewUseCase([]loader.LoadFunc{hostLoader}, executor.NewDefaultExecutor())
+	}
+	run := func(t *testing.T, input string, inline ...string) string {
+		var stdout, stderr bytes.Buffer
+		ctx := executor.ContextWithOutput(context.Background(), &stdout, &stderr)
+		ctx = executor.ContextWithStdin(ctx, strings.NewReader(input))
+		gt.NoError(t, newUseCase().Shell(ctx, inline, "dev"))
+		return stdout.String()
+	}
+
+	t.Run("sets markers for any shell", func(t *testing.T) {
+		shell := filepath.Join(t.TempDir(), "myshell")
+		gt.NoError(t, os.WriteFile(shell, []byte("#!/bin/sh\necho \"$1 $ZENV_ACTIVE $ZENV_PROFILE $HOST $NAME\"\n"), 0o700))
+		t.Setenv("SHELL", shell)
+
+		gt.Equal(t, run(t, "", "NAME=zenv"), "-i 1 dev dev.local zenv\n")
+	})
+
+	t.Run("prefixes the bash prompt after the user's bashrc", func(t *testing.T) {
+		bash, err := exec.LookPath("bash")
+		if err != nil {
+			t.Skip("bash is not available")
+		}
+		home := t.TempDir()
+		gt.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte("PS1='base$ '\n"), 0o600))
+		t.Setenv("HOME", home)
+		t.Setenv("SHELL", bash)
+
+		gt.Equal(t, run(t, "echo \"[$PS1]\"\n"), "[(zenv:dev) base$ ]\n")
+	})
+}
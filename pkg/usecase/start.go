@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// OnExit decides what Start does when one of its processes exits
+type OnExit string
+
+const (
+	// OnExitStop stops the other processes when any process exits
+	OnExitStop OnExit = "stop"
+	// OnExitFailure stops the other processes only when a process fails
+	OnExitFailure OnExit = "failure"
+	// OnExitContinue lets the other processes run until they exit
+	OnExitContinue OnExit = "continue"
+)
+
+// ParseOnExit validates s as an OnExit value
+func ParseOnExit(s string) (OnExit, error) {
+	switch o := OnExit(s); o {
+	case OnExitStop, OnExitFailure, OnExitContinue:
+		return o, nil
+	}
+	return "", goerr.New("invalid on-exit value, expected stop, failure or continue", goerr.V("value", s))
+}
+
+// StartOptions controls Start
+type StartOptions struct {
+	OnExit OnExit
+	// Output receives the output of all processes, each line prefixed with
+	// the process name. Nil means os.Stdout.
+	Output io.Writer
+	// Color colors the prefix of each process
+	Color bool
+}
+
+// prefixColors are the ANSI colors cycled through for process prefixes
+var prefixColors = []string{"36", "33", "32", "35", "34", "31"}
+
+// Start resolves the environment once and runs procs concurrently with it.
+// Inline variables (KEY=value) in inline are set for every process. It
+// returns the error of the first process that exited by itself with one.
+func (uc *UseCase) Start(ctx context.Context, inline []string, procs []*model.Process, opts StartOptions) error {
+	logger := ctxlog.From(ctx)
+
+	inlineEnvVars, _, _ := uc.parseInline(inline)
+	envVars, _, err := uc.loadEnvVars(ctx, inlineEnvVars)
+	if err != nil {
+		return err
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	onExit := opts.OnExit
+	if onExit == "" {
+		onExit = OnExitStop
+	}
+
+	width := 0
+	for _, p := range procs {
+		width = max(width, len(p.Name))
+	}
+	var mu sync.Mutex
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		proc           *model.Process
+		stdout, stderr *prefixWriter
+		err            error
+	}
+	results := make(chan result, len(procs))
+	for i, p := range procs {
+		prefix := prefixLabel(p.Name, width, i, opts.Color)
+		stdout := &prefixWriter{out: out, mu: &mu, prefix: prefix}
+		stderr := &prefixWriter{out: out, mu: &mu, prefix: prefix}
+
+		procCtx := executor.ContextWithOutput(ctx, stdout, stderr)
+		// Processes must not compete for zenv's stdin
+		procCtx = executor.ContextWithStdin(procCtx, strings.NewReader(""))
+		// Stopping a process must also stop the commands it started
+		procCtx = executor.ContextWithProcessGroup(procCtx)
+		logger.Info("starting process", "process", p.Name, "command", p.Command)
+		go func() {
+			err := uc.Executor(procCtx, "sh", []string{"-c", p.Command}, envVars)
+			results <- result{proc: p, stdout: stdout, stderr: stderr, err: err}
+		}()
+	}
+
+	var firstErr error
+	stopping := false
+	for range procs {
+		r := <-results
+		r.stdout.Flush()
+		r.stderr.Flush()
+		if stopping {
+			// Exits caused by stopping are expected
+			continue
+		}
+		fmt.Fprintf(r.stderr, "exited (%s)\n", exitDescription(r.err))
+
+		if r.err != nil && firstErr == nil {
+			firstErr = goerr.Wrap(r.err, "process failed", goerr.V("process", r.proc.Name))
+		}
+		if onExit == OnExitStop || (onExit == OnExitFailure && r.err != nil) {
+			logger.Info("stopping processes", "exited", r.proc.Name)
+			stopping = true
+			cancel()
+		}
+	}
+	return firstErr
+}
+
+// prefixLabel returns the "name | " prefix of the index-th of several
+// outputs, padded to width and optionally coloured
+func prefixLabel(name string, width, index int, color bool) string {
+	label := fmt.Sprintf("%-*s | ", width, name)
+	if color {
+		label = "\x1b[" + prefixColors[index%len(prefixColors)] + "m" + label + "\x1b[0m"
+	}
+	return label
+}
+
+// prefixWriter writes complete lines to out, each prefixed with prefix. Writers
+// sharing mu never interleave their lines.
+type prefixWriter struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a remaining partial line
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, _ = io.WriteString(w.out, w.prefix)
+	_, _ = w.out.Write(line)
+}
@@ -0,0 +1,70 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestStart(t *testing.T) {
+	newUseCase := func() *usecase.UseCase {
+		tokenLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+			return []*model.EnvVar{{Name: "TOKEN", Value: "s3cr3t-value", Secret: true, Source: model.SourceYAML}}, nil
+		}
+		return usecase.NewUseCase([]loader.LoadFunc{tokenLoader}, executor.NewDefaultExecutor())
+	}
+
+	t.Run("output is prefixed and redacted per process", func(t *testing.T) {
+		var out bytes.Buffer
+		procs := []*model.Process{
+			{Name: "web", Command: `echo "token $TOKEN"; printf partial`},
+			{Name: "worker", Command: `echo "$GREETING" >&2`},
+		}
+		err := newUseCase().Start(context.Background(), []string{"GREETING=hello"}, procs,
+			usecase.StartOptions{OnExit: usecase.OnExitContinue, Output: &out})
+		gt.NoError(t, err)
+
+		lines := strings.Split(out.String(), "\n")
+		gt.A(t, lines).Has("web    | partial").Has("worker | hello").Has("worker | exited (exit code 0)")
+		gt.S(t, out.String()).Contains("web    | token ").NotContains("s3cr3t-value")
+	})
+
+	t.Run("stop ends the other processes when one exits", func(t *testing.T) {
+		var out bytes.Buffer
+		procs := []*model.Process{
+			{Name: "quick", Command: "exit 3"},
+			{Name: "slow", Command: "sleep 30"},
+		}
+		begin := time.Now()
+		err := newUseCase().Start(context.Background(), nil, procs, usecase.StartOptions{Output: &out})
+		gt.Error(t, err)
+		gt.Equal(t, model.GetExitCode(err), 3)
+		gt.True(t, time.Since(begin) < 10*time.Second)
+	})
+
+	t.Run("failure keeps running after a successful exit", func(t *testing.T) {
+		var out bytes.Buffer
+		procs := []*model.Process{
+			{Name: "setup", Command: "true"},
+			{Name: "slow", Command: "sleep 0.3; echo done"},
+		}
+		err := newUseCase().Start(context.Background(), nil, procs,
+			usecase.StartOptions{OnExit: usecase.OnExitFailure, Output: &out})
+		gt.NoError(t, err)
+		gt.S(t, out.String()).Contains("slow  | done\n")
+	})
+}
+
+func TestParseOnExit(t *testing.T) {
+	gt.Equal(t, gt.R1(usecase.ParseOnExit("failure")).NoError(t), usecase.OnExitFailure)
+	_, err := usecase.ParseOnExit("restart")
+	gt.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+)
+
+// TaskRunner runs the tasks declared in the configuration
+type TaskRunner struct {
+	Tasks model.Tasks
+	// Profile, if set, is used instead of the profiles of the tasks
+	Profile string
+	// NewUseCase returns the use case that runs a command with the
+	// environment of profile
+	NewUseCase func(profile string) *UseCase
+}
+
+// Run runs the dependencies of the task named name and then the task itself
+// with extraArgs appended to its command. Inline variables (KEY=value) in
+// inline are set for every task.
+func (r *TaskRunner) Run(ctx context.Context, name string, inline []string, extraArgs []string) error {
+	logger := ctxlog.From(ctx)
+
+	order, err := r.Tasks.Order(name)
+	if err != nil {
+		return err
+	}
+
+	for _, taskName := range order {
+		task := r.Tasks[taskName]
+		profile := r.Profile
+		if profile == "" {
+			profile = task.Profile
+		}
+
+		uc := r.NewUseCase(profile)
+		uc.EnableTemplate = uc.EnableTemplate || task.Template
+		args := slices.Clone(inline)
+		for _, key := range sortedKeys(task.Env) {
+			args = append(args, key+"="+task.Env[key])
+		}
+		args = append(args, task.Command...)
+		taskCtx := ctx
+		if taskName == name {
+			args = append(args, extraArgs...)
+		} else {
+			// Only the requested task is restarted in watch mode
+			uc.Watch = false
+			// zenv must outlive dependencies to run the tasks after them
+			taskCtx = executor.ContextWithSpawn(ctx, "task dependencies")
+		}
+
+		logger.Info("running task", "task", taskName, "profile", profile)
+		if err := uc.Run(taskCtx, args); err != nil {
+			return goerr.Wrap(err, "task failed", goerr.V("task", taskName))
+		}
+	}
+	return nil
+}
+
+// List writes the tasks with their descriptions and dependencies to w
+func (r *TaskRunner) List(w io.Writer) {
+	names := r.Tasks.Names()
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	for _, name := range names {
+		task := r.Tasks[name]
+		desc := task.Description
+		if len(task.Depends) > 0 {
+			desc = strings.TrimSpace(fmt.Sprintf("%s (depends on %s)", desc, strings.Join(task.Depends, ", ")))
+		}
+		line := fmt.Sprintf("%-*s  %s", width, name, desc)
+		_, _ = fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
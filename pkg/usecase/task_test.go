@@ -0,0 +1,119 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestTaskRunner(t *testing.T) {
+	tasks := model.Tasks{
+		"build": {Command: []string{"make"}, Profile: "dev"},
+		"psql": {
+			Description: "Connect to the database",
+			Command:     []string{"psql", "-h", "{{ .DB_HOST }}"},
+			Env:         map[string]string{"PGAPPNAME": "zenv"},
+			Template:    true,
+			Depends:     []string{"build"},
+		},
+	}
+
+	type call struct {
+		profile string
+		cmd     string
+		args    []string
+		env     map[string]string
+	}
+
+	newRunner := func(calls *[]call, profile string) *usecase.TaskRunner {
+		return &usecase.TaskRunner{
+			Tasks:   tasks,
+			Profile: profile,
+			NewUseCase: func(profile string) *usecase.UseCase {
+				hostLoader := func(ctx context.Context) ([]*model.EnvVar, error) {
+					return []*model.EnvVar{{Name: "DB_HOST", Value: "db-" + profile, Source: model.SourceYAML}}, nil
+				}
+				return usecase.NewUseCase([]loader.LoadFunc{hostLoader}, func(ctx context.Context, cmd string, args []string, envVars []*model.EnvVar) error {
+					env := map[string]string{}
+					for _, v := range envVars {
+						env[v.Name] = v.Value
+					}
+					*calls = append(*calls, call{profile: profile, cmd: cmd, args: args, env: env})
+					return nil
+				})
+			},
+		}
+	}
+
+	t.Run("dependencies run first with their own settings", func(t *testing.T) {
+		var calls []call
+		err := newRunner(&calls, "").Run(context.Background(), "psql", []string{"TOKEN:=secret"}, []string{"-U", "admin"})
+		gt.NoError(t, err)
+
+		gt.A(t, calls).Length(2)
+		gt.Equal(t, calls[0].profile, "dev")
+		gt.Equal(t, calls[0].cmd, "make")
+		gt.Equal(t, calls[0].env["TOKEN"], "secret")
+		gt.Equal(t, calls[0].env["PGAPPNAME"], "")
+
+		gt.Equal(t, calls[1].profile, "")
+		gt.Equal(t, calls[1].cmd, "psql")
+		gt.Equal(t, calls[1].args, []string{"-h", "db-", "-U", "admin"})
+		gt.Equal(t, calls[1].env["PGAPPNAME"], "zenv")
+		gt.Equal(t, calls[1].env["TOKEN"], "secret")
+	})
+
+	t.Run("profile given on the command line wins", func(t *testing.T) {
+		var calls []call
+		gt.NoError(t, newRunner(&calls, "prod").Run(context.Background(), "psql", nil, nil))
+		gt.Equal(t, calls[0].profile, "prod")
+		gt.Equal(t, calls[1].args, []string{"-h", "db-prod"})
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		var calls []call
+		gt.Error(t, newRunner(&calls, "").Run(context.Background(), "deploy", nil, nil))
+		gt.A(t, calls).Length(0)
+	})
+
+	t.Run("list tasks", func(t *testing.T) {
+		var buf bytes.Buffer
+		newRunner(nil, "").List(&buf)
+		gt.Equal(t, buf.String(), "build\npsql   Connect to the database (depends on build)\n")
+	})
+}
+
+// TestTaskRunnerExecHelper runs tasks for TestTaskRunnerExec with exec mode
+// enabled, since exec replaces the process.
+func TestTaskRunnerExecHelper(t *testing.T) {
+	if os.Getenv("ZENV_TEST_TASK_HELPER") == "" {
+		t.Skip("helper for TestTaskRunnerExec")
+	}
+
+	runner := &usecase.TaskRunner{
+		Tasks: model.Tasks{
+			"build": {Command: []string{"echo", "BUILD"}},
+			"test":  {Command: []string{"echo", "TEST"}, Depends: []string{"build"}},
+		},
+		NewUseCase: func(profile string) *usecase.UseCase {
+			return usecase.NewUseCase(nil, executor.NewDefaultExecutor(executor.WithExecMode(executor.ExecAuto)))
+		},
+	}
+	gt.NoError(t, runner.Run(context.Background(), "test", nil, nil))
+	os.Exit(0)
+}
+
+func TestTaskRunnerExec(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTaskRunnerExecHelper$")
+	cmd.Env = append(os.Environ(), "ZENV_TEST_TASK_HELPER=1")
+	out := gt.R1(cmd.Output()).NoError(t)
+	gt.Equal(t, string(out), "BUILD\nTEST\n")
+}
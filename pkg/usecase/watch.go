@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/m-mizutani/ctxlog"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+)
+
+// DefaultWatchDebounce is how long watched files must stay unchanged before
+// the command is restarted
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// watchPollInterval is how often watched files are checked for changes
+var watchPollInterval = 200 * time.Millisecond
+
+// fileStamp identifies a version of a watched file
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			stamps[p] = fileStamp{}
+			continue
+		}
+		stamps[p] = fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamps
+}
+
+// watchRun is a running command
+type watchRun struct {
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func (uc *UseCase) start(ctx context.Context, command string, commandArgs []string, envVars []*model.EnvVar) *watchRun {
+	ctx, cancel := context.WithCancel(ctx)
+	run := &watchRun{cancel: cancel, done: make(chan error, 1)}
+	go func() {
+		run.done <- uc.execute(ctx, command, commandArgs, envVars)
+	}()
+	return run
+}
+
+// stop terminates the command gracefully through the executor and waits for it
+func (r *watchRun) stop() {
+	r.cancel()
+	<-r.done
+}
+
+// watch runs the command and restarts it when a file that contributed to the
+// environment changes. The command is stopped like on cancellation, so it gets
+// SIGTERM and the kill grace period. If the command exits by itself, zenv keeps
+// waiting for changes until it receives a terminating signal.
+func (uc *UseCase) watch(ctx context.Context, inlineEnvVars []*model.EnvVar, command string, commandArgs []string) error {
+	logger := ctxlog.From(ctx)
+
+	// zenv has to stay alive to restart the command
+	ctx = executor.ContextWithSpawn(ctx, "watch")
+
+	envVars, files, err := uc.loadEnvVars(ctx, inlineEnvVars)
+	if err != nil {
+		return err
+	}
+	stamps := statFiles(files)
+	logger.Debug("watching files", "files", files)
+
+	debounce := uc.WatchDebounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	// The executor forwards these to the command while it runs; here they
+	// only tell that zenv should exit instead of waiting for changes.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	run := uc.start(ctx, command, commandArgs, envVars)
+	running := true
+	var (
+		exitErr    error
+		stopping   bool
+		lastChange time.Time
+	)
+	for {
+		var done chan error
+		if running {
+			done = run.done
+		}
+
+		select {
+		case exitErr = <-done:
+			running = false
+			run.cancel()
+			if stopping || ctx.Err() != nil {
+				return exitErr
+			}
+			fmt.Fprintf(os.Stderr, "zenv: command exited (%s), waiting for changes\n", exitDescription(exitErr))
+
+		case <-sigCh:
+			if !running {
+				return exitErr
+			}
+			stopping = true
+
+		case <-ctx.Done():
+			if running {
+				run.cancel()
+				exitErr = <-run.done
+			}
+			return exitErr
+
+		case <-ticker.C:
+			current := statFiles(files)
+			if !stampsEqual(current, stamps) {
+				stamps = current
+				lastChange = time.Now()
+				continue
+			}
+			if lastChange.IsZero() || time.Since(lastChange) < debounce || stopping {
+				continue
+			}
+			lastChange = time.Time{}
+
+			newEnvVars, newFiles, err := uc.loadEnvVars(ctx, inlineEnvVars)
+			files = mergeFiles(files, newFiles)
+			stamps = statFiles(files)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "zenv: failed to reload environment: %v\n", err)
+				continue
+			}
+
+			changes := envVarChanges(envVars, newEnvVars, uc.MaskPolicy)
+			if len(changes) == 0 && running {
+				logger.Debug("watched files changed but the environment did not")
+				continue
+			}
+			envVars = newEnvVars
+
+			fmt.Fprintln(os.Stderr, "zenv: environment changed, restarting command")
+			writeChanges(os.Stderr, changes)
+
+			if running {
+				run.stop()
+			}
+			run = uc.start(ctx, command, commandArgs, envVars)
+			running = true
+		}
+	}
+}
+
+func exitDescription(err error) string {
+	if err == nil {
+		return "exit code 0"
+	}
+	return err.Error()
+}
+
+func stampsEqual(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !v.modTime.Equal(w.modTime) || v.size != w.size || v.exists != w.exists {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeFiles returns the union of two sorted path lists
+func mergeFiles(a, b []string) []string {
+	merged := slices.Concat(a, b)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// envVarChanges describes the differences between two environments, one line
+// per variable. Secret values are masked with policy.
+func envVarChanges(oldVars, newVars []*model.EnvVar, policy redact.Policy) []string {
+	oldMap := make(map[string]*model.EnvVar, len(oldVars))
+	for _, v := range oldVars {
+		oldMap[v.Name] = v
+	}
+	newMap := make(map[string]*model.EnvVar, len(newVars))
+	for _, v := range newVars {
+		newMap[v.Name] = v
+	}
+
+	show := func(v *model.EnvVar, secret bool) string {
+		if secret {
+			return policy.Mask(v.Name, v.Value)
+		}
+		return v.Value
+	}
+
+	var changes []string
+	for name, n := range newMap {
+		o, ok := oldMap[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("+ %s=%s", name, show(n, n.Secret)))
+		case o.Value != n.Value || o.Secret != n.Secret:
+			secret := o.Secret || n.Secret
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", name, show(o, secret), show(n, secret)))
+		}
+	}
+	for name := range oldMap {
+		if _, ok := newMap[name]; !ok {
+			changes = append(changes, "- "+name)
+		}
+	}
+
+	// Sort by name, ignoring the change marker
+	sort.Slice(changes, func(i, j int) bool { return changes[i][2:] < changes[j][2:] })
+	return changes
+}
+
+func writeChanges(w io.Writer, changes []string) {
+	for _, c := range changes {
+		fmt.Fprintf(w, "  %s\n", c)
+	}
+}
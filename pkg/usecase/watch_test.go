@@ -0,0 +1,87 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+	"github.com/m-mizutani/zenv/v2/pkg/executor"
+	"github.com/m-mizutani/zenv/v2/pkg/loader"
+	"github.com/m-mizutani/zenv/v2/pkg/model"
+	"github.com/m-mizutani/zenv/v2/pkg/redact"
+	"github.com/m-mizutani/zenv/v2/pkg/usecase"
+)
+
+func TestEnvVarChanges(t *testing.T) {
+	oldVars := []*model.EnvVar{
+		{Name: "SAME", Value: "1"},
+		{Name: "CHANGED", Value: "old"},
+		{Name: "REMOVED", Value: "x"},
+		{Name: "TOKEN", Value: "old-secret-value", Secret: true},
+	}
+	newVars := []*model.EnvVar{
+		{Name: "SAME", Value: "1"},
+		{Name: "CHANGED", Value: "new"},
+		{Name: "ADDED", Value: "y"},
+		{Name: "TOKEN", Value: "new-secret-value", Secret: true},
+	}
+
+	changes := usecase.EnvVarChanges(oldVars, newVars, redact.Policy{})
+	gt.Equal(t, changes, []string{
+		"+ ADDED=y",
+		"~ CHANGED: old -> new",
+		"- REMOVED",
+		"~ TOKEN: ***** -> *****",
+	})
+
+	changes = usecase.EnvVarChanges(oldVars, newVars, redact.Policy{Kind: redact.PolicyName})
+	gt.A(t, changes).Contains([]string{"~ TOKEN: [REDACTED:TOKEN] -> [REDACTED:TOKEN]"})
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	outPath := filepath.Join(dir, "out")
+	gt.NoError(t, os.WriteFile(envPath, []byte("FOO=1\n"), 0o600))
+
+	uc := usecase.NewUseCase(
+		[]loader.LoadFunc{loader.NewDotEnvLoader(envPath)},
+		executor.NewDefaultExecutor(executor.WithKillGrace(time.Second)),
+	)
+	uc.Watch = true
+	uc.WatchDebounce = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- uc.Run(ctx, []string{"sh", "-c", `echo "$FOO" >> "$0"; exec sleep 30`, outPath})
+	}()
+
+	waitForOutput := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if data, _ := os.ReadFile(outPath); string(data) == want {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		data, _ := os.ReadFile(outPath)
+		t.Fatalf("output = %q, want %q", data, want)
+	}
+
+	waitForOutput("1\n")
+	gt.NoError(t, os.WriteFile(envPath, []byte("FOO=22\n"), 0o600))
+	waitForOutput("1\n22\n")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after cancellation")
+	}
+}